@@ -0,0 +1,179 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessLogFormat int
+
+const (
+	AccessLogJSON AccessLogFormat = iota
+	// AccessLogCommon is the NCSA Common Log Format.
+	AccessLogCommon
+	// AccessLogCombined is Common Log Format plus referer and user agent.
+	AccessLogCombined
+)
+
+// AccessLogConfig configures AccessLog.
+type AccessLogConfig struct {
+	Format AccessLogFormat
+	// Output defaults to os.Stdout.
+	Output io.Writer
+	// SampleRates logs only this fraction (0 to 1) of requests whose route
+	// starts with the key, e.g. {"/health": 0, "/v1/search": 0.1}. The
+	// longest matching prefix wins; other routes and 5xx responses are
+	// always logged.
+	SampleRates map[string]float64
+	// RedactParams are query parameters whose values are replaced; nil uses
+	// DefaultRedactParams. Matching ignores case and covers param[...].
+	RedactParams []string
+	// UserID returns the authenticated user for the entry. By default the
+	// "user_id" or "id" context key set by the auth middleware is used.
+	UserID func(c *gin.Context) string
+}
+
+var DefaultRedactParams = []string{
+	"token", "access_token", "refresh_token", "password", "secret",
+	"api_key", "apikey", "key", "signature", "code",
+}
+
+const redacted = "REDACTED"
+
+// AccessLogEntry is what AccessLog records for one request.
+type AccessLogEntry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Route     string        `json:"route"`
+	URI       string        `json:"uri"`
+	Proto     string        `json:"proto"`
+	Status    int           `json:"status"`
+	Latency   time.Duration `json:"-"`
+	LatencyMS float64       `json:"latency_ms"`
+	Bytes     int           `json:"bytes"`
+	ClientIP  string        `json:"client_ip"`
+	UserID    string        `json:"user_id,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+	Referer   string        `json:"referer,omitempty"`
+	UserAgent string        `json:"user_agent,omitempty"`
+}
+
+// AccessLog writes one entry per request once the handler chain returns.
+// Register it after RequestID so entries carry the request id.
+func AccessLog(cfg AccessLogConfig) gin.HandlerFunc {
+	if cfg.Output == nil {
+		cfg.Output = os.Stdout
+	}
+	if cfg.RedactParams == nil {
+		cfg.RedactParams = DefaultRedactParams
+	}
+	if cfg.UserID == nil {
+		cfg.UserID = contextUserID
+	}
+	var mu sync.Mutex
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		status := c.Writer.Status()
+		if status < 500 && !sampled(route, cfg.SampleRates) {
+			return
+		}
+		entry := AccessLogEntry{
+			Time:      start,
+			Method:    c.Request.Method,
+			Route:     route,
+			URI:       redactURI(c.Request.URL.Path, c.Request.URL.RawQuery, cfg.RedactParams),
+			Proto:     c.Request.Proto,
+			Status:    status,
+			Latency:   time.Since(start),
+			Bytes:     max(c.Writer.Size(), 0),
+			ClientIP:  c.ClientIP(),
+			UserID:    cfg.UserID(c),
+			RequestID: GetRequestID(c),
+			Referer:   c.Request.Referer(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		entry.LatencyMS = float64(entry.Latency.Microseconds()) / 1000
+
+		line := entry.format(cfg.Format)
+		mu.Lock()
+		cfg.Output.Write(line)
+		mu.Unlock()
+	}
+}
+
+func (e AccessLogEntry) format(format AccessLogFormat) []byte {
+	var buf bytes.Buffer
+	if format == AccessLogJSON {
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(e)
+		return buf.Bytes()
+	}
+	bytesSent := "-"
+	if e.Bytes > 0 {
+		bytesSent = strconv.Itoa(e.Bytes)
+	}
+	fmt.Fprintf(&buf, "%s - %s [%s] %q %d %s",
+		e.ClientIP,
+		orDash(e.UserID),
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method+" "+e.URI+" "+e.Proto,
+		e.Status,
+		bytesSent,
+	)
+	if format == AccessLogCombined {
+		fmt.Fprintf(&buf, " %q %q", orDash(e.Referer), orDash(e.UserAgent))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func sampled(route string, rates map[string]float64) bool {
+	rate, matched := 1.0, -1
+	for prefix, r := range rates {
+		if strings.HasPrefix(route, prefix) && len(prefix) > matched {
+			rate, matched = r, len(prefix)
+		}
+	}
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// redactURI replaces the values of sensitive parameters, keeping the rest
+// of the query string as sent.
+func redactURI(path string, rawQuery string, params []string) string {
+	if rawQuery == "" {
+		return path
+	}
+	q := ParseOrderedQuery(rawQuery)
+	q.Redact(redacted, params...)
+	return path + "?" + q.Encode()
+}
+
+func contextUserID(c *gin.Context) string {
+	for _, key := range []string{"user_id", "id"} {
+		if v, ok := c.Get(key); ok && v != nil {
+			return toString(v)
+		}
+	}
+	return ""
+}
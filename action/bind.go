@@ -0,0 +1,310 @@
+package action
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TechAlkurn/core/lib"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is a problem with a single request parameter.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// BindErrors collects every FieldError found while binding.
+type BindErrors []FieldError
+
+func (e BindErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Field + ": " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e BindErrors) FieldErrors() []FieldError {
+	return e
+}
+
+func (e FilterErrors) FieldErrors() []FieldError {
+	fields := make([]FieldError, len(e))
+	for i, err := range e {
+		fields[i] = FieldError{Field: err.Param, Message: err.Message}
+	}
+	return fields
+}
+
+// fieldErrorer is implemented by errors that Gin.Failed renders per field.
+type fieldErrorer interface {
+	FieldErrors() []FieldError
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	dateTimeType      = reflect.TypeOf(lib.DateTime{})
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Bind maps query parameters onto the struct pointed to by target and then
+// runs gin's validator over it. Supported tags:
+//
+//	Page    int       `query:"page" default:"1" binding:"min=1"`
+//	Status  string    `query:"status" enum:"active,inactive"`
+//	Ids     []uint32  `query:"ids"`                       // ids=1&ids=2 or ids=1,2
+//	From    time.Time `query:"from" time_format:"2006-01-02"`
+//	Search  *string   `query:"q"`                         // nil when absent
+//
+// The parameter name falls back to the form, then json tag, then field name.
+// Conversion and validation problems are returned together as BindErrors.
+func (r *Context) Bind(target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind target must be a pointer to a struct")
+	}
+	names := make(map[string]string)
+	errs := r.bindStruct(rv.Elem(), names)
+	if binding.Validator != nil {
+		// Fields that failed to convert are left out, their zero value
+		// would only add a second, misleading error.
+		failed := make(map[string]bool, len(errs))
+		for _, fe := range errs {
+			failed[fe.Field] = true
+		}
+		if err := binding.Validator.ValidateStruct(target); err != nil {
+			var verrs validator.ValidationErrors
+			if !errors.As(err, &verrs) {
+				return err
+			}
+			for _, fe := range verrs {
+				name, ok := names[fe.StructField()]
+				if !ok {
+					name = fe.Field()
+				}
+				if !failed[name] {
+					errs = append(errs, FieldError{Field: name, Message: validationMessage(fe)})
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (r *Context) bindStruct(v reflect.Value, names map[string]string) BindErrors {
+	var errs BindErrors
+	query := r.Request.URL.Query()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			errs = append(errs, r.bindStruct(fv, names)...)
+			continue
+		}
+		name := paramName(field)
+		if name == "-" {
+			continue
+		}
+		names[field.Name] = name
+
+		values, ok := query[name]
+		if list, isList := query[name+"[]"]; isList {
+			values, ok = append(values, list...), true
+		}
+		if !ok || (len(values) == 1 && values[0] == "") {
+			def, hasDefault := field.Tag.Lookup("default")
+			if !hasDefault {
+				continue
+			}
+			values = []string{def}
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			allowed := strings.Split(enum, ",")
+			if err := checkEnum(values, allowed, fv.Kind() == reflect.Slice); err != nil {
+				errs = append(errs, FieldError{Field: name, Message: err.Error()})
+				continue
+			}
+		}
+		if err := setParam(fv, values, field.Tag.Get("time_format")); err != nil {
+			errs = append(errs, FieldError{Field: name, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+func paramName(field reflect.StructField) string {
+	for _, key := range []string{"query", "form", "json"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			if name := strings.Split(tag, ",")[0]; name != "" {
+				return name
+			}
+		}
+	}
+	return lib.ToLower(field.Name)
+}
+
+func splitValues(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func checkEnum(values []string, allowed []string, multi bool) error {
+	if multi {
+		values = splitValues(values)
+	} else {
+		values = values[:1]
+	}
+	for _, value := range values {
+		if !lib.InArray(value, allowed) {
+			return fmt.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func setParam(v reflect.Value, values []string, layout string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		parts := splitValues(values)
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValue(slice.Index(i), part, layout); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setValue(v, values[0], layout)
+}
+
+func setValue(v reflect.Value, raw string, layout string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), raw, layout); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	switch v.Type() {
+	case timeType, dateTimeType:
+		t, err := parseParamTime(raw, layout)
+		if err != nil {
+			return err
+		}
+		if v.Type() == dateTimeType {
+			v.Set(reflect.ValueOf(lib.DateTime{Time: t}))
+		} else {
+			v.Set(reflect.ValueOf(t))
+		}
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("%q is not valid", raw)
+		}
+		return nil
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid duration", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid boolean", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid integer", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid unsigned integer", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", raw)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported parameter type %s", v.Type())
+	}
+	return nil
+}
+
+func parseParamTime(raw string, layout string) (time.Time, error) {
+	if layout == "unix" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a valid unix time", raw)
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	layouts := timeLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l, raw, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a valid date", raw)
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "len":
+		return "must have length " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	}
+	return fmt.Sprintf("failed the %q rule", fe.Tag())
+}
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type bindParams struct {
+	IDs    []uint32 `query:"ids"`
+	Page   int      `query:"page" default:"1" binding:"min=1"`
+	Limit  int      `query:"limit" binding:"max=50"`
+	Status string   `query:"status" enum:"active,inactive"`
+	Search *string  `query:"q"`
+}
+
+func TestBind(t *testing.T) {
+	var params bindParams
+	if err := Request("ids=1,2&ids[]=3&status=active&q=go").Bind(&params); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(params.IDs, []uint32{1, 2, 3}) || params.Page != 1 || params.Status != "active" || params.Search == nil || *params.Search != "go" {
+		t.Errorf("got %+v", params)
+	}
+}
+
+func TestBindReportsConversionAndValidationErrors(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{"ids=x&page=0", []string{"ids", "page"}},
+		{"page=abc&limit=100", []string{"page", "limit"}},
+		{"status=deleted&limit=51", []string{"status", "limit"}},
+	} {
+		var params bindParams
+		err := Request(tt.query).Bind(&params)
+		var errs BindErrors
+		if !errors.As(err, &errs) {
+			t.Errorf("%s: got %v, want BindErrors", tt.query, err)
+			continue
+		}
+		var fields []string
+		for _, fe := range errs {
+			fields = append(fields, fe.Field)
+		}
+		if !reflect.DeepEqual(fields, tt.want) {
+			t.Errorf("%s: got errors for %v, want %v (%v)", tt.query, fields, tt.want, errs)
+		}
+	}
+}
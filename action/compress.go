@@ -0,0 +1,309 @@
+package action
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionConfig configures Compress. Zero fields take the defaults of
+// DefaultCompression.
+type CompressionConfig struct {
+	// MinSize is the smallest body, in bytes, worth compressing.
+	MinSize int
+	// ContentTypes lists compressible media types. An entry ending in "/*"
+	// matches the whole type, e.g. "text/*".
+	ContentTypes []string
+	// Encodings in order of server preference, among "br", "gzip", "deflate".
+	Encodings []string
+	// Level is passed to the encoder; 0 uses each encoder's default.
+	Level int
+}
+
+var DefaultCompression = CompressionConfig{
+	MinSize: 1024,
+	ContentTypes: []string{
+		"application/json",
+		"application/vnd.api+json",
+		"application/problem+json",
+		"application/x-ndjson",
+		"application/xml",
+		"application/javascript",
+		"text/*",
+	},
+	Encodings: []string{"br", "gzip", "deflate"},
+}
+
+func (cfg CompressionConfig) withDefaults() CompressionConfig {
+	if cfg.MinSize == 0 {
+		cfg.MinSize = DefaultCompression.MinSize
+	}
+	if cfg.ContentTypes == nil {
+		cfg.ContentTypes = DefaultCompression.ContentTypes
+	}
+	if cfg.Encodings == nil {
+		cfg.Encodings = DefaultCompression.Encodings
+	}
+	return cfg
+}
+
+// Compress compresses response bodies with the best encoding the client
+// accepts. Bodies below MinSize, other content types, bodyless statuses and
+// responses that already carry a Content-Encoding pass through untouched.
+//
+// The compressed body is written to the next writer in a single Write, so
+// Compress can sit inside SafeResponseMiddleware. Streams that Flush are
+// written chunk by chunk, which a buffered SafeResponseWriter accepts by
+// switching to streaming.
+func Compress(cfg CompressionConfig) gin.HandlerFunc {
+	cfg = cfg.withDefaults()
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		encoding := acceptedEncoding(c.GetHeader("Accept-Encoding"), cfg.Encodings)
+		addVary(c.Writer.Header(), "Accept-Encoding")
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, config: &cfg, encoding: encoding}
+		c.Writer = writer
+		defer func() {
+			c.Writer = writer.ResponseWriter
+			if value := recover(); value != nil {
+				writer.Reset()
+				panic(value)
+			}
+			writer.close()
+		}()
+		c.Next()
+	}
+}
+
+// acceptedEncoding picks the encoding with the highest q-value; ties go to
+// the server's order. "*" stands for any supported encoding.
+func acceptedEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range supported {
+		q := -1.0
+		for _, r := range parseAccept(header) {
+			if r.mediaType == encoding {
+				q = r.q
+				break
+			}
+			if r.mediaType == "*" && q < 0 {
+				q = r.q
+			}
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+func newEncoder(encoding string, level int, w io.Writer) io.WriteCloser {
+	switch encoding {
+	case "br":
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		return brotli.NewWriterLevel(w, level)
+	case "deflate":
+		if level == 0 {
+			level = zlib.DefaultCompression
+		}
+		encoder, err := zlib.NewWriterLevel(w, level)
+		if err != nil {
+			return zlib.NewWriter(w)
+		}
+		return encoder
+	default:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		encoder, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return gzip.NewWriter(w)
+		}
+		return encoder
+	}
+}
+
+// compressWriter holds the body back until it knows whether compression
+// pays off: MinSize bytes arrived, the handler flushed, or it finished.
+type compressWriter struct {
+	gin.ResponseWriter
+	config   *CompressionConfig
+	encoding string
+
+	pending  bytes.Buffer // body before the decision
+	out      bytes.Buffer // compressed bytes not yet passed on
+	encoder  io.WriteCloser
+	decided  bool
+	compress bool
+	size     int
+	written  bool
+	// passed is set once bytes reached the next writer.
+	passed bool
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.written = true
+	w.size += len(data)
+	if !w.decided {
+		w.pending.Write(data)
+		if w.pending.Len() >= w.config.MinSize {
+			w.decide(true)
+		}
+		return len(data), nil
+	}
+	if w.compress {
+		return w.encoder.Write(data)
+	}
+	w.passed = true
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(true)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.written || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Size() int {
+	if w.written {
+		return w.size
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush passes on everything written so far, compressed when compressing.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.compress {
+		if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+			flusher.Flush()
+		}
+		w.passOn()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide fixes whether the body is compressed and releases what was held
+// back. large reports whether the body is (or may grow) past MinSize.
+func (w *compressWriter) decide(large bool) {
+	w.decided = true
+	w.weakenETag()
+	header := w.Header()
+	status := w.Status()
+	w.compress = large &&
+		header.Get("Content-Encoding") == "" &&
+		status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified &&
+		compressible(header.Get("Content-Type"), w.config.ContentTypes)
+	if !w.compress {
+		if w.pending.Len() > 0 {
+			w.passed = true
+			w.ResponseWriter.Write(w.pending.Bytes())
+			w.pending.Reset()
+		}
+		return
+	}
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	w.encoder = newEncoder(w.encoding, w.config.Level, &w.out)
+	w.encoder.Write(w.pending.Bytes())
+	w.pending.Reset()
+}
+
+func (w *compressWriter) passOn() {
+	if w.out.Len() > 0 {
+		w.passed = true
+		w.ResponseWriter.Write(w.out.Bytes())
+		w.out.Reset()
+	}
+}
+
+// Reset discards the response so far, provided none of it was sent or the
+// next writer can discard it too.
+func (w *compressWriter) Reset() bool {
+	if w.passed && !resetWriter(w.ResponseWriter) {
+		return false
+	}
+	w.pending.Reset()
+	w.out.Reset()
+	w.encoder = nil
+	w.decided, w.compress, w.written, w.passed = false, false, false, false
+	w.size = 0
+	w.Header().Del("Content-Encoding")
+	return true
+}
+
+// Stream forwards the switch to streaming to the next writer.
+func (w *compressWriter) Stream() {
+	startStream(w.ResponseWriter)
+}
+
+// weakenETag makes a strong ETag weak, as it promises the bytes of the
+// identity body. It applies to every response once an encoding was
+// negotiated, compressed or not, so that a 304 carries the validator of
+// the 200 it revalidates. If-None-Match compares weakly (see etagMatches).
+func (w *compressWriter) weakenETag() {
+	header := w.Header()
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+}
+
+func (w *compressWriter) close() {
+	if !w.written {
+		w.weakenETag()
+		return
+	}
+	if !w.decided {
+		w.decide(w.pending.Len() >= w.config.MinSize)
+	}
+	if w.compress {
+		w.encoder.Close()
+		w.passOn()
+	}
+}
+
+func compressible(contentType string, allowed []string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "" {
+		return false
+	}
+	for _, a := range allowed {
+		if a == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
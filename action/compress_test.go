@@ -0,0 +1,63 @@
+package action
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/pkg/protos/gen"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompressETagRevalidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"name":"` + strings.Repeat("x", 2048) + `"}`
+	router := gin.New()
+	router.Use(Compress(DefaultCompression))
+	router.GET("/item", UseCache(CachePolicy{ETag: ETagStrong}), func(c *gin.Context) {
+		NewResponse(c).Response(&gen.Response{Status: http.StatusOK, Data: []byte(body)})
+	})
+	get := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/item", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("gzip", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("got %d with encoding %q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+	if !strings.HasPrefix(etag, "W/") {
+		t.Errorf("compressed response has strong ETag %q", etag)
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _ := io.ReadAll(reader); !strings.Contains(string(decoded), strings.Repeat("x", 2048)) {
+		t.Errorf("decoded body %q", decoded)
+	}
+
+	w = get("gzip", etag)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("revalidation: got %d", w.Code)
+	}
+	if got := w.Header().Get("ETag"); got != etag {
+		t.Errorf("304 has ETag %q, the 200 had %q", got, etag)
+	}
+
+	w = get("", "")
+	if got := w.Header().Get("ETag"); got != strings.TrimPrefix(etag, "W/") || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("identity response has ETag %q and encoding %q", got, w.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,253 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TechAlkurn/core/lib"
+	"github.com/TechAlkurn/core/logger"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusClientClosedRequest is the non-standard 499 used when the client
+// cancelled the request.
+const StatusClientClosedRequest = 499
+
+// Machine-readable codes for errors that do not come with one.
+const (
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeInternal         = "INTERNAL"
+)
+
+const invalidDataMessage = "The given data was invalid."
+
+// APIError is the HTTP view of an error: status code, machine-readable code,
+// a message safe to show the client and optional per-field problems and
+// details. Build one with ToAPIError.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	Details map[string]any
+	// RetryAfter is sent as the Retry-After header, in seconds, when > 0.
+	RetryAfter int
+	cause      error
+}
+
+func (e *APIError) Error() string {
+	if e.cause != nil {
+		return e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
+// Internal reports whether the error is a server fault whose details must
+// not reach the client.
+func (e *APIError) Internal() bool {
+	return e.Status >= http.StatusInternalServerError
+}
+
+// ToAPIError converts err for the client. gRPC statuses are mapped by code
+// and their BadRequest, ErrorInfo and RetryInfo details are kept; a
+// lib.Error keeps its own status, code and details; Bind and Filter errors
+// become 422; context errors become 499/504. Anything else gets fallback
+// (500 when fallback is 0).
+func ToAPIError(err error, fallback int) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	if fallback == 0 {
+		fallback = http.StatusInternalServerError
+	}
+
+	var libErr *lib.Error
+	if errors.As(err, &libErr) {
+		apiErr := &APIError{
+			Status:  libErr.HTTPStatus,
+			Code:    libErr.Code,
+			Message: libErr.Text(),
+			Details: libErr.Details,
+			cause:   err,
+		}
+		// A lib.Error decoded from a backend status keeps its details.
+		fromDetails(apiErr, libErr.GRPCStatus())
+		return apiErr
+	}
+
+	var fields fieldErrorer
+	if errors.As(err, &fields) {
+		return &APIError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    CodeValidationFailed,
+			Message: invalidDataMessage,
+			Fields:  fields.FieldErrors(),
+			cause:   err,
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &APIError{Status: StatusClientClosedRequest, Code: lib.ErrorCode(codes.Canceled), Message: "request is canceled", cause: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &APIError{Status: http.StatusGatewayTimeout, Code: lib.ErrorCode(codes.DeadlineExceeded), Message: "deadline is exceeded", cause: err}
+	}
+
+	if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+		return fromStatus(st, err)
+	}
+
+	code := CodeBadRequest
+	if fallback >= http.StatusInternalServerError {
+		code = CodeInternal
+	} else if fallback != http.StatusBadRequest {
+		code = strings.ToUpper(strings.ReplaceAll(http.StatusText(fallback), " ", "_"))
+	}
+	return &APIError{Status: fallback, Code: code, Message: cleanMessage(err), cause: err}
+}
+
+func fromStatus(st *status.Status, err error) *APIError {
+	apiErr := &APIError{
+		Status:  lib.HTTPStatusFromCode(st.Code()),
+		Code:    lib.ErrorCode(st.Code()),
+		Message: st.Message(),
+		cause:   err,
+	}
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*errdetails.ErrorInfo); ok {
+			if d.GetReason() != "" {
+				apiErr.Code = d.GetReason()
+			}
+			if apiErr.Details == nil {
+				apiErr.Details = map[string]any{}
+			}
+			if d.GetDomain() != "" {
+				apiErr.Details["domain"] = d.GetDomain()
+			}
+			if len(d.GetMetadata()) > 0 {
+				apiErr.Details["metadata"] = d.GetMetadata()
+			}
+		}
+	}
+	if len(apiErr.Details) == 0 {
+		apiErr.Details = nil
+	}
+	fromDetails(apiErr, st)
+	return apiErr
+}
+
+// fromDetails copies the BadRequest field violations and the RetryInfo
+// delay of st onto apiErr. Invalid arguments with field violations become
+// 422, like local validation errors.
+func fromDetails(apiErr *APIError, st *status.Status) {
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				apiErr.Fields = append(apiErr.Fields, FieldError{Field: v.GetField(), Message: v.GetDescription()})
+			}
+		case *errdetails.RetryInfo:
+			if delay := d.GetRetryDelay(); delay != nil {
+				apiErr.RetryAfter = int(delay.AsDuration().Seconds() + 0.5)
+			}
+		}
+	}
+	if len(apiErr.Fields) > 0 && st.Code() == codes.InvalidArgument {
+		apiErr.Status = http.StatusUnprocessableEntity
+		if apiErr.Message == "" {
+			apiErr.Message = invalidDataMessage
+		}
+	}
+}
+
+// cleanMessage strips the "rpc error: code = ... desc = " prefix that
+// status errors wrapped in plain errors still carry.
+func cleanMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	if idx := strings.Index(msg, "desc = "); idx != -1 {
+		msg = msg[idx+len("desc = "):]
+	}
+	return msg
+}
+
+// Error writes err with the status mapped by ToAPIError, falling back to 500.
+func (g *Gin) Error(err error) {
+	g.writeError(ToAPIError(err, http.StatusInternalServerError))
+}
+
+// Response is the body sent for e. Server faults reach the client only as
+// the generic status text, without fields or details.
+func (e *APIError) Response() BaseResponse {
+	if e.Internal() {
+		return BaseResponse{Status: e.Status, Code: e.Code, Message: http.StatusText(e.Status)}
+	}
+	response := BaseResponse{Status: e.Status, Code: e.Code, Message: e.Message}
+	if len(e.Fields) > 0 {
+		response.Errors = e.Fields
+	}
+	if e.Details != nil {
+		response.Details = e.Details
+	}
+	return response
+}
+
+// writeError logs apiErr and renders it. Server faults are logged at error
+// level with everything we know, since the client does not see it.
+func (g *Gin) writeError(apiErr *APIError) {
+	if g.C == nil {
+		Log.Error("Error response with nil context")
+		return
+	}
+	if g.C.Writer.Written() && !resetWriter(g.C.Writer) {
+		g.log().Warn("Error after response was written",
+			logger.Err(apiErr.cause),
+			logger.String("path", g.C.FullPath()),
+		)
+		g.C.Abort()
+		return
+	}
+	if apiErr.Internal() {
+		g.log().Error("Request failed",
+			logger.Int("code", apiErr.Status),
+			logger.String("error_code", apiErr.Code),
+			logger.Err(apiErr.cause),
+			logger.Any("details", apiErr.Details),
+			logger.String("path", g.C.FullPath()),
+		)
+	} else {
+		g.log().Warn("Request failed",
+			logger.Int("code", apiErr.Status),
+			logger.String("error_code", apiErr.Code),
+			logger.Err(apiErr.cause),
+			logger.String("path", g.C.FullPath()),
+		)
+	}
+	if apiErr.RetryAfter > 0 {
+		g.C.Header("Retry-After", strconv.Itoa(apiErr.RetryAfter))
+	}
+	// A replaced success response must not be encoded from its message.
+	g.raw = nil
+	g.render(apiErr.Status, apiErr.Response())
+	g.C.Abort()
+}
+
+// resetWriter discards a response that is still buffered, see SafeBuffered.
+func resetWriter(w gin.ResponseWriter) bool {
+	r, ok := w.(interface{ Reset() bool })
+	return ok && r.Reset()
+}
@@ -0,0 +1,166 @@
+package action
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ETagMode int
+
+const (
+	ETagNone ETagMode = iota
+	// ETagStrong promises byte-identical bodies.
+	ETagStrong
+	// ETagWeak promises semantically equivalent bodies, e.g. when a
+	// compressing proxy may re-encode them.
+	ETagWeak
+)
+
+const cachePolicyKey = "action.cache_policy"
+
+// CachePolicy controls the ETag and Cache-Control headers sent with
+// successful responses of a route.
+type CachePolicy struct {
+	ETag           ETagMode
+	MaxAge         time.Duration
+	SharedMaxAge   time.Duration
+	Public         bool
+	Private        bool
+	NoCache        bool
+	NoStore        bool
+	MustRevalidate bool
+	Immutable      bool
+}
+
+// CacheControl returns the Cache-Control value for p, or "" when p sets
+// nothing.
+func (p CachePolicy) CacheControl() string {
+	var directives []string
+	switch {
+	case p.Public:
+		directives = append(directives, "public")
+	case p.Private:
+		directives = append(directives, "private")
+	}
+	if p.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if p.MaxAge > 0 || ((p.Public || p.Private) && !p.NoStore && !p.NoCache) {
+		directives = append(directives, "max-age="+strconv.Itoa(int(p.MaxAge.Seconds())))
+	}
+	if p.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+strconv.Itoa(int(p.SharedMaxAge.Seconds())))
+	}
+	if p.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+	if p.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// UseCache sets the cache policy for every route it is attached to:
+//
+//	router.GET("/countries", action.UseCache(action.CachePolicy{ETag: action.ETagStrong, Public: true, MaxAge: time.Hour}), h)
+func UseCache(p CachePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(cachePolicyKey, p)
+		c.Next()
+	}
+}
+
+func cachePolicyFor(c *gin.Context) (CachePolicy, bool) {
+	if c == nil {
+		return CachePolicy{}, false
+	}
+	if p, ok := c.Get(cachePolicyKey); ok {
+		policy, ok := p.(CachePolicy)
+		return policy, ok
+	}
+	return CachePolicy{}, false
+}
+
+// SetLastModified sends t as Last-Modified and lets If-Modified-Since
+// requests be answered with 304. Call it before Response.
+func (g *Gin) SetLastModified(t time.Time) {
+	g.lastModified = t
+}
+
+// ETag returns the entity tag for body.
+func ETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// conditional applies the route's cache policy to a successful response
+// and reports whether the client's copy is current, in which case a 304
+// has been written instead of body.
+func (g *Gin) conditional(code int, body []byte) bool {
+	if code < http.StatusOK || code >= http.StatusMultipleChoices {
+		return false
+	}
+	policy, ok := cachePolicyFor(g.C)
+	if !ok && g.lastModified.IsZero() {
+		return false
+	}
+	if value := policy.CacheControl(); value != "" {
+		g.C.Header("Cache-Control", value)
+	}
+
+	var etag string
+	if policy.ETag != ETagNone {
+		etag = ETag(body, policy.ETag == ETagWeak)
+		g.C.Header("ETag", etag)
+	}
+	if !g.lastModified.IsZero() {
+		g.C.Header("Last-Modified", g.lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	method := g.C.Request.Method
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.2.2).
+	if inm := g.C.GetHeader("If-None-Match"); inm != "" {
+		if etag == "" || !etagMatches(inm, etag) {
+			return false
+		}
+	} else if ims := g.C.GetHeader("If-Modified-Since"); ims != "" && !g.lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || g.lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	g.C.Status(http.StatusNotModified)
+	g.C.Writer.WriteHeaderNow()
+	return true
+}
+
+// etagMatches uses the weak comparison If-None-Match calls for.
+func etagMatches(header string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,383 @@
+package action
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/TechAlkurn/core/lib"
+)
+
+type FilterOperator string
+
+const (
+	OpEq      FilterOperator = "eq"
+	OpNe      FilterOperator = "ne"
+	OpGt      FilterOperator = "gt"
+	OpGte     FilterOperator = "gte"
+	OpLt      FilterOperator = "lt"
+	OpLte     FilterOperator = "lte"
+	OpLike    FilterOperator = "like"
+	OpIn      FilterOperator = "in"
+	OpNotIn   FilterOperator = "nin"
+	OpIsNull  FilterOperator = "null"
+	OpBetween FilterOperator = "between"
+)
+
+var filterOperators = map[FilterOperator]string{
+	OpEq: "=", OpNe: "<>", OpGt: ">", OpGte: ">=", OpLt: "<", OpLte: "<=",
+	OpLike: "LIKE", OpIn: "IN", OpNotIn: "NOT IN", OpIsNull: "IS NULL", OpBetween: "BETWEEN",
+}
+
+// FilterCondition is one "filter[field][op]=value" entry with its value
+// converted to the model field's type.
+type FilterCondition struct {
+	Field    string         `json:"field"`
+	Column   string         `json:"-"`
+	Operator FilterOperator `json:"operator"`
+	Value    any            `json:"value"`
+}
+
+type SortField struct {
+	Field  string `json:"field"`
+	Column string `json:"-"`
+	Desc   bool   `json:"desc"`
+}
+
+// QueryFilter is the parsed form of filter[...], sort and fields parameters.
+type QueryFilter struct {
+	Conditions []FilterCondition `json:"conditions"`
+	Sort       []SortField       `json:"sort"`
+	Fields     []string          `json:"fields"`
+}
+
+type FilterError struct {
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+type FilterErrors []FilterError
+
+func (e FilterErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Param + ": " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+type filterField struct {
+	column    string
+	kind      reflect.Type
+	operators map[FilterOperator]bool
+	sortable  bool
+}
+
+// FilterSchema is the whitelist of filterable and sortable fields, keyed by
+// json name like lib.Attributes. A `filter` tag narrows a field:
+//
+//	Status string `json:"status" filter:"eq,in"`
+//	Secret string `json:"secret" filter:"-"`
+//	Notes  string `json:"notes" filter:"like,nosort"`
+type FilterSchema struct {
+	fields map[string]filterField
+}
+
+func NewFilterSchema(model any) *FilterSchema {
+	schema := &FilterSchema{fields: make(map[string]filterField)}
+	t := reflect.TypeOf(model)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return schema
+	}
+	schema.addFields(t, false)
+	return schema
+}
+
+// addFields adds the fields of t to the schema. Embedded structs without a
+// json name are walked, as encoding/json flattens them; their fields
+// without a json name are named after their gorm column, so gorm.Model
+// gives id, created_at, updated_at and deleted_at. Fields of the outer
+// struct win over embedded ones.
+func (s *FilterSchema) addFields(t reflect.Type, embedded bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tagKey, ok := field.Tag.Lookup("json")
+		name := strings.Split(tagKey, ",")[0]
+		if field.Anonymous && name == "" && tagKey != "-" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.addFields(ft, true)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if embedded && name == "" && tagKey != "-" {
+			name, ok = snakeCase(field.Name), true
+		}
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+		if _, exists := s.fields[name]; embedded && exists {
+			continue
+		}
+		tag := field.Tag.Get("filter")
+		if tag == "-" {
+			continue
+		}
+		f := filterField{column: gormColumn(field, name), kind: field.Type, sortable: true}
+		if tag != "" {
+			f.operators = make(map[FilterOperator]bool)
+			for _, op := range strings.Split(tag, ",") {
+				if op == "nosort" {
+					f.sortable = false
+					continue
+				}
+				f.operators[FilterOperator(op)] = true
+			}
+		}
+		s.fields[name] = f
+	}
+}
+
+// snakeCase names a field the way gorm names its column: CreatedAt gives
+// created_at and UserID gives user_id.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func gormColumn(field reflect.StructField, name string) string {
+	for _, part := range strings.Split(field.Tag.Get("gorm"), ";") {
+		if column, ok := strings.CutPrefix(part, "column:"); ok {
+			return column
+		}
+	}
+	return name
+}
+
+func (s *FilterSchema) allows(name string, op FilterOperator) (filterField, bool) {
+	f, ok := s.fields[name]
+	if !ok {
+		return f, false
+	}
+	return f, f.operators == nil || f.operators[op]
+}
+
+var filterKey = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// Filter parses filter[...], sort and fields from the query string and
+// validates them against schema. All problems are reported together as
+// FilterErrors.
+func (r *Context) Filter(schema *FilterSchema) (*QueryFilter, error) {
+	query := r.Request.URL.Query()
+	result := &QueryFilter{}
+	var errs FilterErrors
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		values := query[key]
+		m := filterKey.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		name, op := m[1], FilterOperator(m[2])
+		if op == "" {
+			op = OpEq
+		}
+		if _, known := filterOperators[op]; !known {
+			errs = append(errs, FilterError{Param: key, Message: fmt.Sprintf("unknown operator %q", op)})
+			continue
+		}
+		field, ok := schema.allows(name, op)
+		if !ok {
+			errs = append(errs, FilterError{Param: key, Message: fmt.Sprintf("filtering on %q with %q is not allowed", name, op)})
+			continue
+		}
+		for _, raw := range values {
+			value, err := filterValue(field.kind, op, raw)
+			if err != nil {
+				errs = append(errs, FilterError{Param: key, Message: err.Error()})
+				continue
+			}
+			result.Conditions = append(result.Conditions, FilterCondition{
+				Field: name, Column: field.column, Operator: op, Value: value,
+			})
+		}
+	}
+
+	if order := query.Get("sort"); order != "" {
+		for _, item := range strings.Split(order, ",") {
+			item = strings.TrimSpace(item)
+			desc := strings.HasPrefix(item, "-")
+			name := strings.TrimLeft(item, "-+")
+			field, ok := schema.fields[name]
+			if !ok || !field.sortable {
+				errs = append(errs, FilterError{Param: "sort", Message: fmt.Sprintf("sorting on %q is not allowed", name)})
+				continue
+			}
+			result.Sort = append(result.Sort, SortField{Field: name, Column: field.column, Desc: desc})
+		}
+	}
+
+	if fields := query.Get("fields"); fields != "" {
+		for _, name := range strings.Split(fields, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := schema.fields[name]; !ok {
+				errs = append(errs, FilterError{Param: "fields", Message: fmt.Sprintf("unknown field %q", name)})
+				continue
+			}
+			result.Fields = append(result.Fields, name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+func filterValue(kind reflect.Type, op FilterOperator, raw string) (any, error) {
+	switch op {
+	case OpIsNull:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid boolean", raw)
+		}
+		return b, nil
+	case OpLike:
+		return raw, nil
+	case OpIn, OpNotIn, OpBetween:
+		parts := strings.Split(raw, ",")
+		if op == OpBetween && len(parts) != 2 {
+			return nil, fmt.Errorf("between expects two comma separated values")
+		}
+		values := make([]any, 0, len(parts))
+		for _, part := range parts {
+			v, err := convertFilterValue(kind, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	return convertFilterValue(kind, raw)
+}
+
+var timeLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+
+func convertFilterValue(kind reflect.Type, raw string) (any, error) {
+	for kind.Kind() == reflect.Ptr {
+		kind = kind.Elem()
+	}
+	if kind == reflect.TypeOf(time.Time{}) || kind == reflect.TypeOf(lib.DateTime{}) {
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, raw, time.UTC); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a valid date", raw)
+	}
+	switch kind.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid integer", raw)
+		}
+		return n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid unsigned integer", raw)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid number", raw)
+		}
+		return f, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid boolean", raw)
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+// likeEscaper makes a LIKE value match literally, so a client's % and _
+// are not wildcards.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Where renders the conditions as a SQL fragment with positional arguments,
+// suitable for gorm's db.Where(query, args...).
+func (f *QueryFilter) Where() (string, []any) {
+	clauses := make([]string, 0, len(f.Conditions))
+	args := make([]any, 0, len(f.Conditions))
+	for _, c := range f.Conditions {
+		op := filterOperators[c.Operator]
+		switch c.Operator {
+		case OpIsNull:
+			if b, _ := c.Value.(bool); b {
+				clauses = append(clauses, c.Column+" IS NULL")
+			} else {
+				clauses = append(clauses, c.Column+" IS NOT NULL")
+			}
+		case OpIn, OpNotIn:
+			clauses = append(clauses, fmt.Sprintf("%s %s (?)", c.Column, op))
+			args = append(args, c.Value)
+		case OpBetween:
+			values := c.Value.([]any)
+			clauses = append(clauses, c.Column+" BETWEEN ? AND ?")
+			args = append(args, values[0], values[1])
+		case OpLike:
+			clauses = append(clauses, c.Column+` LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+likeEscaper.Replace(lib.ToString(c.Value))+"%")
+		default:
+			clauses = append(clauses, fmt.Sprintf("%s %s ?", c.Column, op))
+			args = append(args, c.Value)
+		}
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// OrderBy renders the sort fields as an ORDER BY list.
+func (f *QueryFilter) OrderBy() string {
+	order := make([]string, len(f.Sort))
+	for i, s := range f.Sort {
+		order[i] = s.Column + " ASC"
+		if s.Desc {
+			order[i] = s.Column + " DESC"
+		}
+	}
+	return strings.Join(order, ", ")
+}
@@ -0,0 +1,215 @@
+package action
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Formatter shapes a BaseResponse into the body sent to the client. Error
+// responses are those with Status >= 400.
+type Formatter interface {
+	ContentType() string
+	Format(c *gin.Context, response BaseResponse) any
+}
+
+const formatterKey = "action.formatter"
+
+// EnvelopeFormatter writes the {status,message,token,data} envelope as is.
+type EnvelopeFormatter struct{}
+
+func (EnvelopeFormatter) ContentType() string {
+	return "application/json"
+}
+
+func (EnvelopeFormatter) Format(c *gin.Context, response BaseResponse) any {
+	return response
+}
+
+// BareFormatter writes only the data on success and {"message": ...} on error.
+type BareFormatter struct{}
+
+func (BareFormatter) ContentType() string {
+	return "application/json"
+}
+
+func (BareFormatter) Format(c *gin.Context, response BaseResponse) any {
+	if response.Status >= http.StatusBadRequest {
+		body := gin.H{"message": response.Message}
+		if response.Code != "" {
+			body["code"] = response.Code
+		}
+		if response.Errors != nil {
+			body["errors"] = response.Errors
+		}
+		return body
+	}
+	return response.Data
+}
+
+// JSONAPIFormatter writes JSON:API top level documents.
+type JSONAPIFormatter struct{}
+
+func (JSONAPIFormatter) ContentType() string {
+	return "application/vnd.api+json"
+}
+
+type jsonAPIError struct {
+	Status string         `json:"status"`
+	Code   string         `json:"code,omitempty"`
+	Title  string         `json:"title"`
+	Detail string         `json:"detail,omitempty"`
+	Source map[string]any `json:"source,omitempty"`
+	Meta   any            `json:"meta,omitempty"`
+}
+
+func (JSONAPIFormatter) Format(c *gin.Context, response BaseResponse) any {
+	if response.Status >= http.StatusBadRequest {
+		status := strconv.Itoa(response.Status)
+		title := http.StatusText(response.Status)
+		var errs []jsonAPIError
+		if fields, ok := response.Errors.([]FieldError); ok {
+			for _, f := range fields {
+				errs = append(errs, jsonAPIError{
+					Status: status,
+					Code:   response.Code,
+					Title:  title,
+					Detail: f.Message,
+					Source: map[string]any{"parameter": f.Field},
+				})
+			}
+		}
+		if len(errs) == 0 {
+			errs = append(errs, jsonAPIError{Status: status, Code: response.Code, Title: title, Detail: response.Message, Meta: response.Details})
+		}
+		return gin.H{"errors": errs}
+	}
+	doc := gin.H{"data": response.Data}
+	meta := gin.H{}
+	if response.Message != "" {
+		meta["message"] = response.Message
+	}
+	if response.Token != "" {
+		meta["token"] = response.Token
+	}
+	if response.Meta != nil {
+		meta["page"] = response.Meta
+	}
+	if len(meta) > 0 {
+		doc["meta"] = meta
+	}
+	if response.Links != nil {
+		doc["links"] = response.Links
+	}
+	return doc
+}
+
+// ProblemFormatter writes errors as RFC 7807 application/problem+json and
+// delegates successful responses to Success (the envelope when nil).
+type ProblemFormatter struct {
+	// TypeBase prefixes the status code to build the problem "type" URI.
+	// "about:blank" is used when empty.
+	TypeBase string
+	Success  Formatter
+}
+
+func (f ProblemFormatter) ContentType() string {
+	return "application/problem+json"
+}
+
+func (f ProblemFormatter) Format(c *gin.Context, response BaseResponse) any {
+	if response.Status < http.StatusBadRequest {
+		if f.Success != nil {
+			return f.Success.Format(c, response)
+		}
+		return response
+	}
+	problemType := "about:blank"
+	if f.TypeBase != "" {
+		problemType = strings.TrimRight(f.TypeBase, "/") + "/" + strconv.Itoa(response.Status)
+	}
+	problem := gin.H{
+		"type":   problemType,
+		"title":  http.StatusText(response.Status),
+		"status": response.Status,
+	}
+	if response.Message != "" {
+		problem["detail"] = response.Message
+	}
+	if c != nil && c.Request != nil {
+		problem["instance"] = c.Request.URL.Path
+	}
+	if response.Code != "" {
+		problem["code"] = response.Code
+	}
+	if response.Errors != nil {
+		problem["errors"] = response.Errors
+	}
+	if response.Details != nil {
+		problem["details"] = response.Details
+	}
+	return problem
+}
+
+// ContentTypeFor reports the envelope's type for successful responses.
+func (f ProblemFormatter) ContentTypeFor(response BaseResponse) string {
+	if response.Status < http.StatusBadRequest {
+		if f.Success != nil {
+			return f.Success.ContentType()
+		}
+		return EnvelopeFormatter{}.ContentType()
+	}
+	return f.ContentType()
+}
+
+// contentType reports the media type written for response. Formatters whose
+// type depends on the response implement ContentTypeFor.
+func contentType(f Formatter, response BaseResponse) string {
+	if typed, ok := f.(interface{ ContentTypeFor(BaseResponse) string }); ok {
+		return typed.ContentTypeFor(response)
+	}
+	return f.ContentType()
+}
+
+var (
+	formattersMu     sync.RWMutex
+	DefaultFormatter Formatter = EnvelopeFormatter{}
+	formatters                 = map[string]Formatter{
+		JSONAPIFormatter{}.ContentType(): JSONAPIFormatter{},
+		ProblemFormatter{}.ContentType(): ProblemFormatter{},
+	}
+)
+
+// RegisterFormatter makes f selectable by clients sending its content type in
+// the Accept header.
+func RegisterFormatter(f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[f.ContentType()] = f
+}
+
+// UseFormatter sets the formatter for every route of a group:
+//
+//	api := router.Group("/v2", action.UseFormatter(action.JSONAPIFormatter{}))
+func UseFormatter(f Formatter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(formatterKey, f)
+		c.Next()
+	}
+}
+
+// groupFormatter returns the route group's formatter, or DefaultFormatter.
+// A formatter named in Accept takes precedence; see negotiate.
+func groupFormatter(c *gin.Context) Formatter {
+	if c != nil {
+		if f, ok := c.Get(formatterKey); ok {
+			if formatter, ok := f.(Formatter); ok {
+				return formatter
+			}
+		}
+	}
+	return DefaultFormatter
+}
@@ -0,0 +1,283 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"app/pkg/protos/gen"
+
+	"github.com/TechAlkurn/core/interceptor"
+	"github.com/TechAlkurn/core/lib"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// GatewayCall is the signature of a generated gRPC client method, so
+// client.GetUser can be passed as is.
+type GatewayCall[Req proto.Message] func(ctx context.Context, req Req, opts ...grpc.CallOption) (*gen.Response, error)
+
+// GatewayInput is what a gateway route received. Path params are merged
+// into both Query and Form.
+type GatewayInput struct {
+	Params map[string]string
+	Query  *Context
+	// Form is the "form" object of the body as returned by
+	// lib.ShouldBindJSON; nil for methods without a body.
+	Form []byte
+}
+
+// GatewayConfig configures Gateway.
+type GatewayConfig[Req proto.Message] struct {
+	// Build replaces the default mapping of the input onto the request
+	// message, see Gateway.
+	Build func(c *gin.Context, in *GatewayInput) (Req, error)
+	// Timeout bounds the backend call. Zero keeps the request's deadline.
+	Timeout time.Duration
+	// Page, when set, renders the response with ResponsePage.
+	Page func(c *gin.Context) *Pagination
+}
+
+// Gateway serves a gin route with a gRPC method:
+//
+//	router.GET("/users/:id", action.Gateway(users.GetUser))
+//	router.POST("/users/:id/notes", action.Gateway(users.AddNote))
+//
+// By default the request message is filled from the query string, then the
+// body's "form" object, then the path params, later sources winning. Keys
+// match the proto field or JSON name; unknown keys are dropped. Query and
+// path values are converted to the field's type; message and map fields
+// can only come from the body.
+//
+// The Authorization header and request id are forwarded as metadata and
+// the backend response is rendered with Response. Bad input is answered
+// with 400/422 and backend errors are mapped by Error.
+func Gateway[Req proto.Message](call GatewayCall[Req], cfg ...GatewayConfig[Req]) gin.HandlerFunc {
+	var config GatewayConfig[Req]
+	if len(cfg) > 0 {
+		config = cfg[0]
+	}
+	if config.Build == nil {
+		config.Build = buildGatewayRequest[Req]
+	}
+	return func(c *gin.Context) {
+		g := NewResponse(c)
+		in, err := gatewayInput(c)
+		if err != nil {
+			g.Abort(err)
+			return
+		}
+		req, err := config.Build(c, in)
+		if err != nil {
+			g.Abort(err)
+			return
+		}
+
+		ctx := interceptor.WithToken(OutgoingContext(c), c.GetHeader("Authorization"))
+		if config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+			defer cancel()
+		}
+		resp, err := call(ctx, req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+		if config.Page != nil {
+			g.ResponsePage(resp, config.Page(c))
+			return
+		}
+		g.Response(resp)
+	}
+}
+
+func gatewayInput(c *gin.Context) (*GatewayInput, error) {
+	in := &GatewayInput{
+		Params: make(map[string]string, len(c.Params)),
+		Query:  Request(c.Request.URL.RawQuery),
+	}
+	params := make(map[string]any, len(c.Params))
+	for _, p := range c.Params {
+		in.Params[p.Key] = p.Value
+		params[p.Key] = p.Value
+	}
+	in.Query.SetQueryParams(params)
+
+	switch c.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		form, err := lib.ShouldBindJSON(c, params)
+		if err != nil {
+			return nil, err
+		}
+		in.Form = form
+	}
+	return in, nil
+}
+
+// buildGatewayRequest is the default GatewayConfig.Build.
+func buildGatewayRequest[Req proto.Message](c *gin.Context, in *GatewayInput) (Req, error) {
+	var zero Req
+	req := reflect.New(reflect.TypeOf(zero).Elem()).Interface().(Req)
+	fields := req.ProtoReflect().Descriptor().Fields()
+
+	values := make(map[string]any)
+	// keys remembers the key each value was sent as, for error messages.
+	keys := make(map[string]string)
+	var errs BindErrors
+	// ids=1&ids[]=2 both fill ids, so values are grouped by field first.
+	query := make(map[protoreflect.FieldDescriptor][]string)
+	params := in.Query.Request.URL.Query()
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		raw := params[key]
+		fd := fieldByKey(fields, key)
+		if fd == nil || fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			continue
+		}
+		query[fd] = append(query[fd], raw...)
+		keys[string(fd.Name())] = strings.TrimSuffix(key, "[]")
+	}
+	for fd, raw := range query {
+		name := string(fd.Name())
+		value, err := fieldValue(fd, raw)
+		if err != nil {
+			errs = append(errs, FieldError{Field: keys[name], Message: err.Error()})
+			continue
+		}
+		values[name] = value
+	}
+	if len(errs) > 0 {
+		return req, sortFieldErrors(errs)
+	}
+
+	if in.Form != nil {
+		var form map[string]json.RawMessage
+		if err := json.Unmarshal(in.Form, &form); err != nil {
+			return req, err
+		}
+		for key, value := range form {
+			if fd := fieldByKey(fields, key); fd != nil {
+				values[string(fd.Name())] = value
+				keys[string(fd.Name())] = key
+			}
+		}
+		// lib.ShouldBindJSON merged the path params into the form as strings.
+		for key := range in.Params {
+			if fd := fieldByKey(fields, key); fd != nil {
+				if value, err := fieldValue(fd, []string{in.Params[key]}); err == nil {
+					values[string(fd.Name())] = value
+					keys[string(fd.Name())] = key
+				}
+			}
+		}
+	}
+
+	opts := protojson.UnmarshalOptions{DiscardUnknown: true}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return req, err
+	}
+	if err = opts.Unmarshal(data, req); err == nil {
+		return req, nil
+	}
+	// Find the fields protojson rejected, so the client gets a 422 naming
+	// them rather than a 400 with the parser's message.
+	for name, value := range values {
+		field, _ := json.Marshal(map[string]any{name: value})
+		if fieldErr := opts.Unmarshal(field, req.ProtoReflect().New().Interface()); fieldErr != nil {
+			errs = append(errs, FieldError{Field: keys[name], Message: protojsonMessage(fieldErr)})
+		}
+	}
+	if len(errs) == 0 {
+		return req, err
+	}
+	return req, sortFieldErrors(errs)
+}
+
+// sortFieldErrors orders errs by field, as they were collected from maps.
+func sortFieldErrors(errs BindErrors) BindErrors {
+	slices.SortFunc(errs, func(a, b FieldError) int { return strings.Compare(a.Field, b.Field) })
+	return errs
+}
+
+// protojsonMessage drops the "proto: (line 1:9): " prefix of a protojson
+// error. The library varies the space after "proto:" on purpose, so it is
+// cut at the first space-like rune.
+func protojsonMessage(err error) string {
+	message := err.Error()
+	if i := strings.LastIndex(message, "): "); i >= 0 {
+		return message[i+3:]
+	}
+	if rest, ok := strings.CutPrefix(message, "proto:"); ok {
+		return strings.TrimLeftFunc(rest, unicode.IsSpace)
+	}
+	return message
+}
+
+// fieldByKey finds the field for a proto or JSON name. A trailing "[]", as
+// in ids[]=1, is ignored like Bind does.
+func fieldByKey(fields protoreflect.FieldDescriptors, key string) protoreflect.FieldDescriptor {
+	key = strings.TrimSuffix(key, "[]")
+	if fd := fields.ByName(protoreflect.Name(key)); fd != nil {
+		return fd
+	}
+	return fields.ByJSONName(key)
+}
+
+// fieldValue converts query values for fd. protojson takes numbers and
+// bytes as strings, and enums as names, so only booleans and enum numbers
+// need converting; enum names are checked.
+func fieldValue(fd protoreflect.FieldDescriptor, raw []string) (any, error) {
+	convert := func(s string) (any, error) {
+		switch fd.Kind() {
+		case protoreflect.BoolKind:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, errors.New("must be true or false")
+			}
+			return b, nil
+		case protoreflect.EnumKind:
+			if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+				return n, nil
+			}
+			// protojson would drop an unknown name along with unknown keys.
+			if fd.Enum().Values().ByName(protoreflect.Name(s)) == nil {
+				return nil, fmt.Errorf("must be one of %s", enumNames(fd.Enum()))
+			}
+		}
+		return s, nil
+	}
+	if !fd.IsList() {
+		return convert(raw[0])
+	}
+	list := make([]any, 0, len(raw))
+	for _, s := range splitValues(raw) {
+		value, err := convert(s)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+func enumNames(enum protoreflect.EnumDescriptor) string {
+	values := enum.Values()
+	names := make([]string, values.Len())
+	for i := range names {
+		names[i] = string(values.Get(i).Name())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,52 @@
+package action
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"app/pkg/protos/gen"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGatewayRepeatedQueryField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got *descriptorpb.FileDescriptorProto
+	call := func(ctx context.Context, req *descriptorpb.FileDescriptorProto, opts ...grpc.CallOption) (*gen.Response, error) {
+		got = req
+		return &gen.Response{Status: http.StatusOK, Data: []byte(`{}`)}, nil
+	}
+	router := gin.New()
+	router.GET("/files/:name", Gateway(call))
+
+	for _, tt := range []struct {
+		query string
+		want  []int32
+	}{
+		{"public_dependency=1&public_dependency=2", []int32{1, 2}},
+		{"public_dependency[]=1&public_dependency[]=2", []int32{1, 2}},
+		{"publicDependency[]=1,2&publicDependency=3", []int32{3, 1, 2}},
+	} {
+		got = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a.proto?"+tt.query+"&dependency[]=b.proto", nil))
+		if w.Code != http.StatusOK || got == nil {
+			t.Errorf("%s: got %d %s", tt.query, w.Code, w.Body.String())
+			continue
+		}
+		if !reflect.DeepEqual(got.GetPublicDependency(), tt.want) || got.GetName() != "a.proto" || !reflect.DeepEqual(got.GetDependency(), []string{"b.proto"}) {
+			t.Errorf("%s: got %v", tt.query, got)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a.proto?public_dependency[]=1&public_dependency[]=x", nil))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid item: got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,382 @@
+package action
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotAcceptable is returned by an Encoder that cannot represent a body,
+// e.g. CSV for non-list data or protobuf without the original message.
+var ErrNotAcceptable = errors.New("representation not acceptable")
+
+// Encoder serialises a formatted response body. raw is the backend message
+// the body was built from, when there is one.
+type Encoder interface {
+	Encode(body any, raw proto.Message) ([]byte, error)
+}
+
+type EncoderFunc func(body any, raw proto.Message) ([]byte, error)
+
+func (f EncoderFunc) Encode(body any, raw proto.Message) ([]byte, error) {
+	return f(body, raw)
+}
+
+const jsonMediaType = "application/json"
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		jsonMediaType:                     EncoderFunc(encodeJSON),
+		"application/xml":                 EncoderFunc(encodeXML),
+		"text/xml":                        EncoderFunc(encodeXML),
+		"application/msgpack":             EncoderFunc(encodeMsgpack),
+		"application/x-msgpack":           EncoderFunc(encodeMsgpack),
+		"application/vnd.msgpack":         EncoderFunc(encodeMsgpack),
+		"text/csv":                        EncoderFunc(encodeCSV),
+		"application/x-protobuf":          EncoderFunc(encodeProtobuf),
+		"application/protobuf":            EncoderFunc(encodeProtobuf),
+		"application/vnd.google.protobuf": EncoderFunc(encodeProtobuf),
+	}
+)
+
+// RegisterEncoder adds or replaces the encoder used for mediaType.
+func RegisterEncoder(mediaType string, e Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[mediaType] = e
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept returns the media ranges of an Accept header ordered by
+// preference, dropping those with q=0.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// negotiation is the outcome of matching Accept against formatters and
+// encoders. mediaType is empty when the formatter's own type applies.
+type negotiation struct {
+	formatter Formatter
+	encoder   Encoder
+	mediaType string
+	// next is tried when encoder cannot represent the body; a range such as
+	// text/* can match several encoders.
+	next *negotiation
+}
+
+// negotiate picks the envelope and representation for c. A registered
+// formatter type (e.g. application/vnd.api+json) selects that envelope as
+// JSON; an encoder type selects the route's envelope in that encoding.
+// A type/* range matches every encoder of that type, JSON first when it is
+// one of them; */* and a missing Accept header fall back to JSON.
+func negotiate(c *gin.Context) (negotiation, bool) {
+	result := negotiation{formatter: groupFormatter(c), encoder: EncoderFunc(encodeJSON)}
+	if c == nil || c.Request == nil {
+		return result, true
+	}
+	accept := c.GetHeader("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return result, true
+	}
+
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	for _, r := range parseAccept(accept) {
+		if f, ok := formatters[r.mediaType]; ok {
+			result.formatter = f
+			return result, true
+		}
+		if e, ok := encoders[r.mediaType]; ok {
+			result.encoder = e
+			if r.mediaType != jsonMediaType {
+				result.mediaType = r.mediaType
+			}
+			return result, true
+		}
+		major, wildcard := strings.CutSuffix(r.mediaType, "/*")
+		if !wildcard {
+			continue
+		}
+		if major == "*" || strings.HasPrefix(jsonMediaType, major+"/") {
+			return result, true
+		}
+		if matched, ok := matchType(result.formatter, major); ok {
+			return matched, true
+		}
+	}
+	return result, false
+}
+
+// matchType chains the encoders registered under major/ in media type order.
+// Callers hold encodersMu.
+func matchType(formatter Formatter, major string) (negotiation, bool) {
+	var mediaTypes []string
+	for mediaType := range encoders {
+		if strings.HasPrefix(mediaType, major+"/") {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		return negotiation{}, false
+	}
+	sort.Strings(mediaTypes)
+	var next *negotiation
+	for i := len(mediaTypes) - 1; i >= 0; i-- {
+		next = &negotiation{formatter: formatter, encoder: encoders[mediaTypes[i]], mediaType: mediaTypes[i], next: next}
+	}
+	return *next, true
+}
+
+func encodeJSON(body any, raw proto.Message) ([]byte, error) {
+	return json.Marshal(body)
+}
+
+// toGeneric normalises body through JSON so every encoder sees the same
+// maps, slices and scalars the JSON client would.
+func toGeneric(body any) (any, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+func encodeMsgpack(body any, raw proto.Message) ([]byte, error) {
+	generic, err := toGeneric(body)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	var handle codec.MsgpackHandle
+	if err := codec.NewEncoder(&buf, &handle).Encode(numbersToNative(generic)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// numbersToNative converts json.Number values to int64 or float64 so binary
+// encoders keep numeric types.
+func numbersToNative(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = numbersToNative(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = numbersToNative(item)
+		}
+		return v
+	}
+	return value
+}
+
+func encodeXML(body any, raw proto.Message) ([]byte, error) {
+	generic, err := toGeneric(body)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	if err := writeXML(encoder, "response", generic); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeXML writes maps as child elements in key order, slices as repeated
+// <item> elements and scalars as text.
+func writeXML(encoder *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := writeXML(encoder, key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := writeXML(encoder, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// xmlName makes a JSON key usable as an element name.
+func xmlName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "item"
+	}
+	return b.String()
+}
+
+// encodeCSV writes list data as CSV. The list is the body itself or found
+// under "data" (e.g. the envelope around a paged {"data": [...]}); rows that
+// are objects produce one column per key.
+func encodeCSV(body any, raw proto.Message) ([]byte, error) {
+	generic, err := toGeneric(body)
+	if err != nil {
+		return nil, err
+	}
+	rows, ok := listData(generic)
+	if !ok {
+		return nil, ErrNotAcceptable
+	}
+
+	var header []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if m, isMap := row.(map[string]any); isMap {
+			for key := range m {
+				if !seen[key] {
+					seen[key] = true
+					header = append(header, key)
+				}
+			}
+		}
+	}
+	sort.Strings(header)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if len(header) == 0 {
+		header = []string{"value"}
+		writer.Write(header)
+		for _, row := range rows {
+			writer.Write([]string{csvCell(row)})
+		}
+	} else {
+		writer.Write(header)
+		for _, row := range rows {
+			m, _ := row.(map[string]any)
+			record := make([]string, len(header))
+			for i, key := range header {
+				record[i] = csvCell(m[key])
+			}
+			writer.Write(record)
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// listData finds the list in body, following "data" members.
+func listData(body any) ([]any, bool) {
+	for depth := 0; depth < 3; depth++ {
+		switch v := body.(type) {
+		case []any:
+			return v, true
+		case map[string]any:
+			body = v["data"]
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func csvCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// encodeProtobuf returns the backend message bytes unchanged; the envelope is
+// not applied since the client decodes the message type itself.
+func encodeProtobuf(body any, raw proto.Message) ([]byte, error) {
+	if raw == nil {
+		return nil, ErrNotAcceptable
+	}
+	return proto.Marshal(raw)
+}
@@ -0,0 +1,255 @@
+package action
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/TechAlkurn/core/lib"
+)
+
+type PaginationMode string
+
+const (
+	OffsetPagination PaginationMode = "offset"
+	CursorPagination PaginationMode = "cursor"
+)
+
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+type PaginationConfig struct {
+	DefaultPerPage int
+	MaxPerPage     int
+	// CursorSecret signs cursors so clients cannot forge positions. Cursors
+	// are only base64 encoded when it is empty.
+	CursorSecret []byte
+	// OpaqueCursors leaves the cursor parameter undecoded, for backends
+	// that issue their own string cursors. It is read from CursorToken.
+	OpaqueCursors bool
+}
+
+var DefaultPagination = PaginationConfig{DefaultPerPage: 20, MaxPerPage: 100}
+
+// Pagination holds the parsed page request. Offset mode reads page/per_page;
+// cursor mode reads cursor/limit and is selected when a cursor is present.
+type Pagination struct {
+	Mode    PaginationMode
+	Page    int
+	PerPage int
+	Cursor  map[string]any
+	// CursorToken is the cursor parameter as received, with OpaqueCursors.
+	CursorToken string
+	// Total and NextCursor are filled by the handler (or taken from the
+	// backend's "total"/"next_cursor" data fields) before rendering. A
+	// string next cursor goes to NextCursorToken and is linked unchanged.
+	Total           int64
+	NextCursor      map[string]any
+	NextCursorToken string
+
+	config PaginationConfig
+	raw    string
+}
+
+type PaginationMeta struct {
+	Total       *int64 `json:"total,omitempty"`
+	PerPage     int    `json:"per_page"`
+	CurrentPage int    `json:"current_page,omitempty"`
+	PageCount   *int64 `json:"page_count,omitempty"`
+	HasMore     *bool  `json:"has_more,omitempty"`
+}
+
+type PaginationLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// Paginate reads pagination parameters, clamping per_page/limit to
+// config.MaxPerPage. Invalid numbers are returned as BindErrors.
+func (r *Context) Paginate(config PaginationConfig) (*Pagination, error) {
+	if config.DefaultPerPage <= 0 {
+		config.DefaultPerPage = DefaultPagination.DefaultPerPage
+	}
+	if config.MaxPerPage <= 0 {
+		config.MaxPerPage = DefaultPagination.MaxPerPage
+	}
+	p := &Pagination{Mode: OffsetPagination, Page: 1, PerPage: config.DefaultPerPage, config: config, raw: r.ToQueryString()}
+
+	sizeParam := "per_page"
+	if r.HasQueryParam("cursor") {
+		p.Mode = CursorPagination
+		sizeParam = "limit"
+		if cursor := r.Query("cursor"); cursor != "" && config.OpaqueCursors {
+			p.CursorToken = cursor
+		} else if cursor != "" {
+			values, err := DecodeCursor(cursor, config.CursorSecret)
+			if err != nil {
+				return nil, err
+			}
+			p.Cursor = values
+		}
+	} else if page := r.Query("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return nil, BindErrors{{Field: "page", Message: "must be a positive integer"}}
+		}
+		p.Page = n
+	}
+	if size := r.Query(sizeParam); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			return nil, BindErrors{{Field: sizeParam, Message: "must be a positive integer"}}
+		}
+		p.PerPage = min(n, config.MaxPerPage)
+	}
+	// Offset must not overflow into a negative SQL OFFSET.
+	if p.Page-1 > math.MaxInt/p.PerPage {
+		return nil, BindErrors{{Field: "page", Message: "is too large"}}
+	}
+	return p, nil
+}
+
+// Offset is the number of rows to skip in offset mode.
+func (p *Pagination) Offset() int {
+	if p.Mode == CursorPagination {
+		return 0
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+func (p *Pagination) Limit() int {
+	return p.PerPage
+}
+
+func (p *Pagination) PageCount() int64 {
+	if p.PerPage <= 0 {
+		return 0
+	}
+	return (p.Total + int64(p.PerPage) - 1) / int64(p.PerPage)
+}
+
+// Meta builds the "meta" block of a paginated response.
+func (p *Pagination) Meta() PaginationMeta {
+	meta := PaginationMeta{PerPage: p.PerPage}
+	if p.Mode == CursorPagination {
+		hasMore := len(p.NextCursor) > 0 || p.NextCursorToken != ""
+		meta.HasMore = &hasMore
+		if p.Total > 0 {
+			meta.Total = &p.Total
+		}
+		return meta
+	}
+	count := p.PageCount()
+	meta.Total = &p.Total
+	meta.CurrentPage = p.Page
+	meta.PageCount = &count
+	return meta
+}
+
+// Links builds self/next/prev URLs for path, preserving every other query
+// parameter through SetQueryParam.
+func (p *Pagination) Links(path string) PaginationLinks {
+	link := func(params map[string]any, drop ...string) string {
+		req := Request(p.raw)
+		for _, key := range drop {
+			req.DeleteQueryParam(key)
+		}
+		req.SetQueryParams(params)
+		if query := req.ToQueryString(); query != "" {
+			return path + "?" + query
+		}
+		return path
+	}
+	links := PaginationLinks{Self: link(nil)}
+	if p.Mode == CursorPagination {
+		next := p.NextCursorToken
+		if len(p.NextCursor) > 0 {
+			if encoded, err := EncodeCursor(p.NextCursor, p.config.CursorSecret); err == nil {
+				next = encoded
+			}
+		}
+		if next != "" {
+			links.Next = link(map[string]any{"cursor": next, "limit": p.PerPage})
+		}
+		return links
+	}
+	if int64(p.Page) < p.PageCount() {
+		links.Next = link(map[string]any{"page": p.Page + 1, "per_page": p.PerPage})
+	}
+	if p.Page > 1 {
+		links.Prev = link(map[string]any{"page": p.Page - 1, "per_page": p.PerPage})
+	}
+	return links
+}
+
+// fromData picks up "total" and "next_cursor" from backend data when the
+// handler has not set them, removing them from the payload. A next_cursor
+// that is neither an object nor a string is left in the data.
+func (p *Pagination) fromData(m map[string]any) {
+	if total, ok := m["total"]; ok {
+		if p.Total == 0 {
+			p.Total = lib.ToInt64(total)
+		}
+		delete(m, "total")
+	}
+	if next, ok := m["next_cursor"]; ok {
+		switch cursor := next.(type) {
+		case map[string]any:
+			if p.NextCursor == nil {
+				p.NextCursor = cursor
+			}
+		case string:
+			if p.NextCursorToken == "" {
+				p.NextCursorToken = cursor
+			}
+		case nil:
+		default:
+			return
+		}
+		delete(m, "next_cursor")
+	}
+}
+
+// EncodeCursor serialises cursor values as base64url JSON, followed by an
+// HMAC-SHA256 signature when secret is set.
+func EncodeCursor(values map[string]any, secret []byte) (string, error) {
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	data := base64.RawURLEncoding.EncodeToString(payload)
+	if len(secret) == 0 {
+		return data, nil
+	}
+	return data + "." + base64.RawURLEncoding.EncodeToString(signCursor(data, secret)), nil
+}
+
+func DecodeCursor(cursor string, secret []byte) (map[string]any, error) {
+	data, sig, signed := strings.Cut(cursor, ".")
+	if len(secret) > 0 {
+		signature, err := base64.RawURLEncoding.DecodeString(sig)
+		if !signed || err != nil || !hmac.Equal(signature, signCursor(data, secret)) {
+			return nil, ErrInvalidCursor
+		}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var values map[string]any
+	if err := json.Unmarshal(payload, &values); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return values, nil
+}
+
+func signCursor(data string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
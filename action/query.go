@@ -0,0 +1,137 @@
+package action
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type queryParam struct {
+	key string
+	// raw is the pair exactly as it appeared in (or will be written to) the
+	// query string.
+	raw string
+}
+
+// OrderedQuery is an editable query string that keeps parameter order and the
+// original encoding of every parameter it does not touch. url.Values would
+// sort keys and re-encode everything, which breaks signed URLs and cache keys.
+type OrderedQuery struct {
+	params []queryParam
+}
+
+func ParseOrderedQuery(raw string) *OrderedQuery {
+	q := &OrderedQuery{}
+	for _, pair := range strings.Split(raw, "&") {
+		if pair == "" {
+			continue
+		}
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		q.params = append(q.params, queryParam{key: key, raw: pair})
+	}
+	return q
+}
+
+func newQueryParam(key string, value string) queryParam {
+	return queryParam{key: key, raw: url.QueryEscape(key) + "=" + url.QueryEscape(value)}
+}
+
+// matches reports whether key is param itself or one of its param[...] entries.
+func matches(key string, param string) bool {
+	return key == param || isNestedParam(key, param)
+}
+
+func (q *OrderedQuery) Has(param string) bool {
+	for _, p := range q.params {
+		if matches(p.key, param) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set replaces every param and param[...] entry with values, written where
+// the first replaced entry was (or appended when param is new).
+func (q *OrderedQuery) Set(param string, values url.Values) {
+	at := -1
+	kept := q.params[:0:0]
+	for _, p := range q.params {
+		if matches(p.key, param) {
+			if at == -1 {
+				at = len(kept)
+			}
+			continue
+		}
+		kept = append(kept, p)
+	}
+	if at == -1 {
+		at = len(kept)
+	}
+	added := pairs(values)
+	q.params = append(kept[:at:at], append(added, kept[at:]...)...)
+}
+
+// Add appends values after the last existing entry for param, or at the end.
+func (q *OrderedQuery) Add(param string, values url.Values) {
+	at := len(q.params)
+	for i, p := range q.params {
+		if matches(p.key, param) {
+			at = i + 1
+		}
+	}
+	added := pairs(values)
+	q.params = append(q.params[:at:at], append(added, q.params[at:]...)...)
+}
+
+func (q *OrderedQuery) Del(param string) {
+	kept := q.params[:0]
+	for _, p := range q.params {
+		if !matches(p.key, param) {
+			kept = append(kept, p)
+		}
+	}
+	q.params = kept
+}
+
+// Redact replaces the value of every entry for params (ignoring case) with
+// mask, keeping the entry where it is.
+func (q *OrderedQuery) Redact(mask string, params ...string) {
+	for i, p := range q.params {
+		key := strings.ToLower(p.key)
+		for _, param := range params {
+			if matches(key, strings.ToLower(param)) {
+				rawKey, _, _ := strings.Cut(p.raw, "=")
+				q.params[i].raw = rawKey + "=" + url.QueryEscape(mask)
+				break
+			}
+		}
+	}
+}
+
+func (q *OrderedQuery) Encode() string {
+	parts := make([]string, len(q.params))
+	for i, p := range q.params {
+		parts[i] = p.raw
+	}
+	return strings.Join(parts, "&")
+}
+
+// pairs flattens values in key order so generated entries are stable.
+func pairs(values url.Values) []queryParam {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var out []queryParam
+	for _, key := range keys {
+		for _, value := range values[key] {
+			out = append(out, newQueryParam(key, value))
+		}
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/TechAlkurn/core/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// PanicReport describes a recovered panic for a PanicSink.
+type PanicReport struct {
+	Value     any
+	Stack     []byte
+	Time      time.Time
+	Method    string
+	Route     string
+	Path      string
+	RequestID string
+}
+
+// PanicSink receives recovered panics, e.g. to forward them to an error
+// tracker. Report runs on the request goroutine; hand slow work off.
+type PanicSink interface {
+	Report(c *gin.Context, report PanicReport)
+}
+
+type PanicSinkFunc func(c *gin.Context, report PanicReport)
+
+func (f PanicSinkFunc) Report(c *gin.Context, report PanicReport) {
+	f(c, report)
+}
+
+// RecoveryConfig configures Recovery.
+type RecoveryConfig struct {
+	// Sink is optional.
+	Sink PanicSink
+}
+
+// Recovery turns a panic in a later handler into a logged error and a 500
+// BaseResponse. When the response is already on its way to the client only
+// the log entry is written. Register it first so it covers every other
+// middleware:
+//
+//	router.Use(action.Recovery(action.RecoveryConfig{}), action.RequestID(), ...)
+func Recovery(cfg RecoveryConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			// net/http uses ErrAbortHandler to abort a response on purpose.
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+			recoverPanic(c, value, cfg.Sink)
+		}()
+		c.Next()
+	}
+}
+
+func recoverPanic(c *gin.Context, value any, sink PanicSink) {
+	g := NewResponse(c)
+	report := PanicReport{
+		Value:     value,
+		Stack:     debug.Stack(),
+		Time:      time.Now(),
+		Method:    c.Request.Method,
+		Route:     c.FullPath(),
+		Path:      c.Request.URL.Path,
+		RequestID: GetRequestID(c),
+	}
+
+	if brokenPipe(value) {
+		g.log().Warn("Client connection lost",
+			logger.Any("panic", value),
+			logger.String("path", report.Route),
+		)
+		c.Abort()
+		return
+	}
+
+	g.log().Error("Panic recovered",
+		logger.String("panic", fmt.Sprint(value)),
+		logger.String("method", report.Method),
+		logger.String("path", report.Route),
+		logger.ByteString("stack", report.Stack),
+	)
+	if sink != nil {
+		reportPanic(g, sink, report)
+	}
+
+	if c.Writer.Written() && !resetWriter(c.Writer) {
+		c.Abort()
+		return
+	}
+	g.render(http.StatusInternalServerError, BaseResponse{
+		Status:  http.StatusInternalServerError,
+		Code:    CodeInternal,
+		Message: http.StatusText(http.StatusInternalServerError),
+	})
+	c.Abort()
+}
+
+// reportPanic keeps a failing sink from taking the response down with it.
+func reportPanic(g *Gin, sink PanicSink, report PanicReport) {
+	defer func() {
+		if value := recover(); value != nil {
+			g.log().Error("Panic sink failed", logger.String("panic", fmt.Sprint(value)))
+		}
+	}()
+	sink.Report(g.C, report)
+}
+
+// brokenPipe reports panics caused by the client going away, for which no
+// response can be written.
+func brokenPipe(value any) bool {
+	err, ok := value.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if errors.As(opErr, &syscallErr) {
+		msg := strings.ToLower(syscallErr.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+	}
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
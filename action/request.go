@@ -3,6 +3,12 @@ package action
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/TechAlkurn/core/lib"
 )
 
 type Context struct {
@@ -12,13 +18,17 @@ type Context struct {
 
 type IRequest interface {
 	Query(key string) string
+	QueryAll(key string) []string
+	QueryMap(key string) map[string]any
 	SetQueryParam(param string, value any)
 	SetQueryParams(params map[string]any)
+	AddQueryParam(param string, value any)
 	DeleteQueryParam(param string)
 	HasQueryParam(param string) bool
 	ToQueryString() string
 	IgnoreNotification() bool
 	IsParam(arg string) bool
+	Bind(target any) error
 }
 
 func NewRequest(uri string) IRequest {
@@ -49,34 +59,96 @@ func (r *Context) Query(key string) string {
 	return r.Request.URL.Query().Get(key)
 }
 
+// QueryAll returns every value of a repeated parameter. Values sent as key[]
+// are included.
+func (r *Context) QueryAll(key string) []string {
+	q := r.Request.URL.Query()
+	return append(q[key], q[key+"[]"]...)
+}
+
+// QueryMap decodes bracket-notation parameters under key into nested maps,
+// e.g. a[b][c]=1 gives QueryMap("a") == {"b": {"c": "1"}}. An empty key
+// decodes the whole query string.
+func (r *Context) QueryMap(key string) map[string]any {
+	all := lib.ParseNestedQuery(r.Request.URL.Query())
+	if key == "" {
+		return all
+	}
+	if m, ok := all[key].(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+// HasQueryParam reports whether key is present, either plain or as the root
+// of bracket-notation parameters.
 func (r *Context) HasQueryParam(key string) bool {
-	_, exists := r.Request.URL.Query()[key]
-	return exists
+	for name := range r.Request.URL.Query() {
+		if name == key || isNestedParam(name, key) {
+			return true
+		}
+	}
+	return false
 }
 
+func isNestedParam(name string, param string) bool {
+	return strings.HasPrefix(name, param+"[")
+}
+
+// SetQueryParam replaces param in place, leaving the order and encoding of
+// every other parameter untouched. Maps and slices are encoded in bracket
+// notation and replace any existing param[...] entries.
 func (r *Context) SetQueryParam(param string, value any) {
-	q := r.Request.URL.Query()
-	q.Set(param, toString(value))
+	q := ParseOrderedQuery(r.Request.URL.RawQuery)
+	q.Set(param, paramValues(param, value))
+	r.setQuery(q)
+}
+
+// AddQueryParam adds value after the existing values of param.
+func (r *Context) AddQueryParam(param string, value any) {
+	q := ParseOrderedQuery(r.Request.URL.RawQuery)
+	q.Add(param, paramValues(param, value))
+	r.setQuery(q)
+}
 
+func (r *Context) setQuery(q *OrderedQuery) {
 	encoded := q.Encode()
 	r.Request.URL.RawQuery = encoded
 	r.Uri = encoded
 }
 
+// paramValues keeps the plain formatting of toString for scalars and only
+// switches to bracket notation for maps and slices.
+func paramValues(param string, value any) url.Values {
+	values := url.Values{}
+	if _, isBytes := value.([]byte); !isBytes && value != nil {
+		if kind := reflect.ValueOf(value).Kind(); kind == reflect.Map || kind == reflect.Slice {
+			lib.AddQueryValues(values, param, value)
+			return values
+		}
+	}
+	values.Add(param, toString(value))
+	return values
+}
+
+// SetQueryParams sets each param; new keys are appended in sorted order so
+// the result does not depend on map iteration.
 func (r *Context) SetQueryParams(params map[string]any) {
-	for key, value := range params {
-		r.SetQueryParam(key, value)
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		r.SetQueryParam(key, params[key])
 	}
 	r.Uri = r.ToQueryString()
 }
 
 func (r *Context) DeleteQueryParam(param string) {
-	q := r.Request.URL.Query()
+	q := ParseOrderedQuery(r.Request.URL.RawQuery)
 	q.Del(param)
-
-	encoded := q.Encode()
-	r.Request.URL.RawQuery = encoded
-	r.Uri = encoded
+	r.setQuery(q)
 }
 
 func (r *Context) ToQueryString() string {
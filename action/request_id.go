@@ -0,0 +1,57 @@
+package action
+
+import (
+	"context"
+
+	"github.com/TechAlkurn/core/lib"
+	"github.com/TechAlkurn/core/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDKey = "action.request_id"
+
+// RequestID accepts the client's X-Request-ID when it is valid, or generates
+// one, and makes it available to handlers, logs and backend calls. The id is
+// echoed in the response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(lib.RequestIDHeader)
+		if !lib.ValidRequestID(id) {
+			id = lib.NewRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(lib.WithRequestID(c.Request.Context(), id))
+		c.Header(lib.RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the id assigned by RequestID, or "".
+func GetRequestID(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	if id := c.GetString(requestIDKey); id != "" {
+		return id
+	}
+	if c.Request != nil {
+		return lib.RequestIDFromContext(c.Request.Context())
+	}
+	return ""
+}
+
+// OutgoingContext is the request context with the request id attached as
+// gRPC metadata, for calls to backends:
+//
+//	resp, err := client.Get(action.OutgoingContext(c), req)
+func OutgoingContext(c *gin.Context) context.Context {
+	return lib.OutgoingContext(c.Request.Context())
+}
+
+// log returns the logger for this request, tagged with its request id.
+func (g *Gin) log() logger.Logger {
+	if id := GetRequestID(g.C); id != "" {
+		return Log.With(logger.String("request_id", id))
+	}
+	return Log
+}
@@ -1,24 +1,34 @@
 package action
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"app/pkg/protos/gen"
 
 	"github.com/TechAlkurn/core/lib"
+	"github.com/TechAlkurn/core/logger"
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
-	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
-var Log = logrus.New()
+// Log is the action package logger; see the logger package for backends.
+var Log = logger.For("action")
 
 type Gin struct {
 	C *gin.Context
+	// raw is the backend response being written, kept for clients that
+	// negotiate the protobuf representation.
+	raw *gen.Response
+	// lastModified is sent as Last-Modified; see SetLastModified.
+	lastModified time.Time
 }
 
 func NewResponse(c *gin.Context) *Gin {
@@ -27,38 +37,73 @@ func NewResponse(c *gin.Context) *Gin {
 
 type BaseResponse struct {
 	Status  int    `json:"status"`
+	Code    string `json:"code,omitempty"`
 	Message string `json:"message,omitempty"`
 	Token   string `json:"token,omitempty"`
 	Data    any    `json:"data"`
+	Meta    any    `json:"meta,omitempty"`
+	Links   any    `json:"links,omitempty"`
+	Errors  any    `json:"errors,omitempty"`
+	Details any    `json:"details,omitempty"`
 }
 
 // Response setting gin.JSON: Pending:-> work on Response
 func (g *Gin) Response(raw *gen.Response) {
+	if response, ok := g.buildResponse(raw); ok {
+		g.raw = raw
+		g.safeJSONWrite(response)
+	}
+}
+
+// ResponsePage writes raw like Response and adds pagination meta and links.
+// "total" and "next_cursor" in the backend data are moved into page. A nil
+// page writes a plain Response.
+func (g *Gin) ResponsePage(raw *gen.Response, page *Pagination) {
+	if page == nil {
+		g.Response(raw)
+		return
+	}
+	response, ok := g.buildResponse(raw)
+	if !ok {
+		return
+	}
+	if m, isMap := response.Data.(map[string]any); isMap {
+		page.fromData(m)
+	}
+	response.Meta = page.Meta()
+	response.Links = page.Links(g.C.Request.URL.Path)
+	g.raw = raw
+	g.safeJSONWrite(response)
+}
+
+// buildResponse converts raw into the response envelope. It returns false
+// when nothing should be written.
+func (g *Gin) buildResponse(raw *gen.Response) (BaseResponse, bool) {
 	if g.C == nil {
 		Log.Error("Nil context in response handler")
-		return
+		return BaseResponse{}, false
 	}
 
 	// Check for context cancellation early
 	if ctxErr := g.C.Request.Context().Err(); ctxErr != nil {
-		Log.Debug("Aborting response due to context error",
-			zap.String("path", g.C.FullPath()),
-			zap.Error(ctxErr),
+		g.log().Debug("Aborting response due to context error",
+			logger.String("path", g.C.FullPath()),
+			logger.Err(ctxErr),
 		)
-		return
+		return BaseResponse{}, false
 	}
 
 	if g.C.Writer.Written() {
-		Log.Warn("Attempted duplicate response write", zap.String("path", g.C.FullPath()))
-		return
+		g.log().Warn("Attempted duplicate response write", logger.String("path", g.C.FullPath()))
+		return BaseResponse{}, false
 	}
 
 	if g.C.Writer.Status() != http.StatusOK {
-		Log.Warn("Failed to write response",
-			zap.Int("status", g.C.Writer.Status()),
-			zap.String("path", g.C.FullPath()),
+		g.log().Warn("Failed to write response",
+			logger.Int("status", g.C.Writer.Status()),
+			logger.String("path", g.C.FullPath()),
 		)
-		return
+		return BaseResponse{}, false
 	}
 
 	// Create response object
@@ -68,24 +113,22 @@ func (g *Gin) Response(raw *gen.Response) {
 	if status := raw.GetStatus(); status >= 100 && status <= 599 {
 		response.Status = int(status)
 	} else {
-		Log.Warn("Invalid status code received", zap.Int32("proto_status", status), zap.String("path", g.C.FullPath()))
+		g.log().Warn("Invalid status code received", logger.Int32("proto_status", status), logger.String("path", g.C.FullPath()))
 	}
-	g.C.Header("Content-Type", "application/json")
 	// Safely handle empty responses
-	rawData := raw.Data
+	rawData := raw.GetData()
 	if lib.IsNil(rawData) {
 		response.Data = []any{} // Set empty array instead of object
-		g.C.JSON(http.StatusOK, response)
-		return
+		return response, true
 	}
 
 	// Convert Protobuf Struct to Go map
 	if len(rawData) > 0 {
 		var parsedData any
 		if err := json.Unmarshal(rawData, &parsedData); err != nil {
-			Log.Warn("Failed to unmarshal response data",
-				zap.Error(err),
-				zap.ByteString("raw", rawData),
+			g.log().Warn("Failed to unmarshal response data",
+				logger.Err(err),
+				logger.ByteString("raw", rawData),
 			)
 			response.Data = json.RawMessage(rawData)
 		} else {
@@ -105,81 +148,137 @@ func (g *Gin) Response(raw *gen.Response) {
 			}
 		}
 	}
-	g.safeJSONWrite(response)
+	return response, true
 }
 
 // Thread-safe JSON writer with context monitoring
 func (g *Gin) safeJSONWrite(response BaseResponse) {
-	ctx := g.C.Request.Context()
 	// Check if the client is already gone before writing
-	if ctx.Err() != nil {
-		Log.Warn("Client disconnected before response write",
-			zap.String("path", g.C.FullPath()),
-			zap.Error(ctx.Err()),
-		)
-		g.C.Abort()
+	if g.disconnected("Client disconnected before response write") != nil {
 		return
 	}
 
 	// Write response synchronously
-	g.C.JSON(http.StatusOK, response)
+	g.render(http.StatusOK, response)
 
 	// Verify if the write succeeded
-	if ctx.Err() != nil {
-		Log.Warn("Client disconnected during response write",
-			zap.String("path", g.C.FullPath()),
-			zap.Error(ctx.Err()),
-		)
-		g.C.Abort()
-	}
+	g.disconnected("Client disconnected during response write")
 }
 
+// Abort writes err and stops the handler chain. gRPC status errors are
+// mapped to their HTTP status; other errors are sent as 400.
 func (g *Gin) Abort(err error) {
 	if g.C == nil {
 		Log.Error("Abort called with nil context")
 		return
 	}
-	Log.Warn("Request aborted",
-		zap.Error(err),
-		zap.String("path", g.C.FullPath()),
-	)
-
-	g.C.JSON(http.StatusBadRequest, BaseResponse{
-		Status:  http.StatusBadRequest,
-		Message: g.cleanErrorMessage(err),
-	})
-	g.C.Abort()
+	g.writeError(ToAPIError(err, http.StatusBadRequest))
 }
 
+// Failed writes err with the given status code, whatever the error is. The
+// error code, field errors and details of gRPC statuses and Bind or Filter
+// errors are still sent; use Abort or Error to take their status too.
 func (g *Gin) Failed(code int, err error) {
 	if g.C == nil {
 		Log.Error("Failed called with nil context")
 		return
 	}
-	Log.Warn("Request failed",
-		zap.Int("code", code),
-		zap.Error(err),
-		zap.String("path", g.C.FullPath()),
-	)
+	apiErr := *ToAPIError(err, code)
+	apiErr.Status = code
+	g.writeError(&apiErr)
+}
 
-	g.C.JSON(code, BaseResponse{
-		Status:  code,
-		Message: g.cleanErrorMessage(err),
-	})
-	g.C.Abort()
+// render shapes response with the Formatter selected for this request and
+// encodes it in the representation negotiated from Accept. When nothing the
+// client accepts can be produced, successful responses become 406 and error
+// responses fall back to JSON so the client still sees what went wrong.
+func (g *Gin) render(code int, response BaseResponse) {
+	body, err := []byte(nil), ErrNotAcceptable
+	n, ok := negotiate(g.C)
+	if ok {
+		formatted := n.formatter.Format(g.C, response)
+		for {
+			body, err = n.encoder.Encode(formatted, g.rawMessage())
+			if !errors.Is(err, ErrNotAcceptable) || n.next == nil {
+				break
+			}
+			n = *n.next
+		}
+	}
+	if errors.Is(err, ErrNotAcceptable) {
+		if response.Status < http.StatusBadRequest {
+			code = http.StatusNotAcceptable
+			response = BaseResponse{Status: code, Message: http.StatusText(code)}
+		}
+		n = negotiation{formatter: groupFormatter(g.C), encoder: EncoderFunc(encodeJSON)}
+		body, err = n.encoder.Encode(n.formatter.Format(g.C, response), nil)
+	}
+	if err != nil {
+		g.log().Error("Failed to encode response",
+			logger.Err(err),
+			logger.String("path", g.C.FullPath()),
+		)
+		g.C.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	mediaType := n.mediaType
+	if mediaType == "" {
+		mediaType = contentType(n.formatter, response)
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "json") || strings.Contains(mediaType, "xml") {
+		mediaType += "; charset=utf-8"
+	}
+	addVary(g.C.Writer.Header(), "Accept")
+	if g.conditional(code, body) {
+		return
+	}
+	g.C.Data(code, mediaType, body)
 }
 
-func (g *Gin) cleanErrorMessage(err error) string {
-	if err == nil {
-		return ""
+// addVary lists value in the Vary header unless it is there already, as a
+// response replaced by an error is rendered a second time.
+func addVary(header http.Header, value string) {
+	for _, line := range header.Values("Vary") {
+		for _, v := range strings.Split(line, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
 	}
-	// Extract meaningful error message
-	msg := err.Error()
-	if idx := strings.Index(msg, "desc = "); idx != -1 {
-		msg = msg[idx+len("desc = "):]
+	header.Add("Vary", value)
+}
+
+// rawMessage returns the backend response for the protobuf encoder, or nil
+// when the response was not built from one.
+func (g *Gin) rawMessage() proto.Message {
+	if g.raw == nil {
+		return nil
 	}
-	// Sanitize sensitive information
-	return lib.ToString(msg)
+	return g.raw
+}
+
+// SafeWriterMode selects how SafeResponseWriter treats the body.
+type SafeWriterMode int
+
+const (
+	// SafeSingleWrite passes the first Write through and rejects the rest.
+	SafeSingleWrite SafeWriterMode = iota
+	// SafeBuffered collects the whole body, however many writes it takes,
+	// and sends it once when the handler chain returns. Until then status,
+	// headers and body can still be replaced, e.g. by an error response.
+	SafeBuffered
+	// SafeStreaming passes every write straight through, for large bodies
+	// and streams.
+	SafeStreaming
+)
+
+// SafeResponseConfig configures SafeResponse.
+type SafeResponseConfig struct {
+	Mode SafeWriterMode
+	// MaxBuffer switches a buffered response to streaming once the body
+	// grows past this many bytes. 0 means no limit.
+	MaxBuffer int
 }
 
 type SafeResponseWriter struct {
@@ -187,39 +286,191 @@ type SafeResponseWriter struct {
 	mu     sync.Mutex
 	wrote  bool
 	status int
+
+	mode      SafeWriterMode
+	maxBuffer int
+	buf       bytes.Buffer
+	// committed is set once a buffered response went out to the client.
+	committed bool
+}
+
+// buffering reports whether the body is still held back. Callers hold mu.
+func (w *SafeResponseWriter) buffering() bool {
+	return w.mode == SafeBuffered && !w.committed
 }
 
 func (w *SafeResponseWriter) Write(data []byte) (int, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.wrote {
+	switch {
+	case w.buffering():
+		w.wrote = true
+		w.buf.Write(data)
+		if w.maxBuffer > 0 && w.buf.Len() > w.maxBuffer {
+			w.commit(false)
+		}
+		return len(data), nil
+	case w.mode == SafeSingleWrite && w.wrote:
 		return 0, fmt.Errorf("response already written")
 	}
 	w.wrote = true
 	return w.ResponseWriter.Write(data)
 }
 
+func (w *SafeResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
 func (w *SafeResponseWriter) WriteHeader(code int) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.buffering() {
+		w.status = code
+		return
+	}
 	if !w.wrote {
 		w.status = code
 		w.ResponseWriter.WriteHeader(code)
 	}
 }
 
+func (w *SafeResponseWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.buffering() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *SafeResponseWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buffering() && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *SafeResponseWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buffering() {
+		return w.wrote
+	}
+	return w.ResponseWriter.Written()
+}
+
+func (w *SafeResponseWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buffering() && w.wrote {
+		return w.buf.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush sends a buffered response so far and switches it to streaming, so
+// handlers that flush (NDJSON, SSE) work in every mode.
+func (w *SafeResponseWriter) Flush() {
+	w.mu.Lock()
+	if w.buffering() {
+		w.commit(false)
+	}
+	w.mu.Unlock()
+	w.ResponseWriter.Flush()
+}
+
+// Stream switches w to SafeStreaming for a body written in many parts,
+// sending whatever a buffered response held so far. The stream helpers
+// call it before their first write.
+func (w *SafeResponseWriter) Stream() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buffering() {
+		w.commit(false)
+	}
+	w.mode = SafeStreaming
+}
+
+// Reset discards a buffered response that has not been sent yet, so that
+// an error response can replace it. It reports whether that was possible.
+func (w *SafeResponseWriter) Reset() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.buffering() {
+		return false
+	}
+	w.buf.Reset()
+	w.wrote = false
+	w.status = 0
+	header := w.Header()
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Encoding", "ETag", "Last-Modified", "Cache-Control"} {
+		header.Del(key)
+	}
+	return true
+}
+
+// commit sends the buffered status and body in one write. Callers hold mu.
+func (w *SafeResponseWriter) commit(final bool) {
+	w.committed = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() == 0 {
+		return
+	}
+	if final && w.Header().Get("Content-Encoding") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	}
+	w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+}
+
+func (w *SafeResponseWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buffering() {
+		w.commit(true)
+		return
+	}
+	// Finalize status code if not set
+	if !w.wrote && w.status == 0 {
+		w.status = http.StatusOK
+		w.ResponseWriter.WriteHeader(http.StatusOK)
+	}
+}
+
 // Middleware to wrap the response writer
 func SafeResponseMiddleware() gin.HandlerFunc {
+	return SafeResponse(SafeResponseConfig{Mode: SafeSingleWrite})
+}
+
+// SafeResponse wraps the response writer in the given mode:
+//
+//	router.Use(action.SafeResponse(action.SafeResponseConfig{Mode: action.SafeBuffered, MaxBuffer: 8 << 20}))
+func SafeResponse(cfg SafeResponseConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		writer := &SafeResponseWriter{ResponseWriter: c.Writer}
+		writer := &SafeResponseWriter{ResponseWriter: c.Writer, mode: cfg.Mode, maxBuffer: cfg.MaxBuffer}
 		c.Writer = writer
+		defer func() {
+			// Drop a half-built buffered response so Recovery can answer
+			// on the underlying writer.
+			if value := recover(); value != nil {
+				writer.Reset()
+				c.Writer = writer.ResponseWriter
+				panic(value)
+			}
+		}()
 		c.Next()
-
-		// Finalize status code if not set
-		if !writer.wrote && writer.status == 0 {
-			writer.WriteHeader(http.StatusOK)
-		}
+		writer.finish()
 	}
 }
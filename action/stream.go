@@ -0,0 +1,273 @@
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TechAlkurn/core/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamSource yields the next item of a stream and io.EOF after the last.
+// Items are *gen.Response (or anything with GetData() []byte), raw JSON
+// []byte, SSEEvent or any value encoding/json can marshal.
+type StreamSource func() (any, error)
+
+// FromStream adapts a gRPC server stream client. Open the stream with
+// c.Request.Context() so the backend call is cancelled with the client.
+//
+//	stream, err := client.Export(c.Request.Context(), req)
+//	...
+//	action.NewResponse(c).StreamNDJSON(action.FromStream(stream))
+func FromStream[T any](stream interface{ Recv() (T, error) }) StreamSource {
+	return func() (any, error) {
+		return stream.Recv()
+	}
+}
+
+// SSEEvent is a single Server-Sent Event. Data is written as is when it is a
+// string or []byte, otherwise as JSON.
+type SSEEvent struct {
+	ID    string
+	Event string
+	Retry int
+	Data  any
+}
+
+// StreamNDJSON writes every item as one line of JSON. A backend error after
+// the first item is reported as a final {"status","message"} line.
+func (g *Gin) StreamNDJSON(next StreamSource) error {
+	return g.stream("application/x-ndjson", next, streamWriter{
+		item: func(i int, data []byte) []byte {
+			return append(data, '\n')
+		},
+		fail: func(response BaseResponse) []byte {
+			data, _ := json.Marshal(response)
+			return append(data, '\n')
+		},
+	})
+}
+
+// StreamJSONArray writes the items as one JSON array, sent in chunks. A
+// backend error after the first item leaves the array unterminated so the
+// client sees invalid JSON rather than silently truncated data.
+func (g *Gin) StreamJSONArray(next StreamSource) error {
+	return g.stream("application/json; charset=utf-8", next, streamWriter{
+		item: func(i int, data []byte) []byte {
+			sep := byte(',')
+			if i == 0 {
+				sep = '['
+			}
+			return append([]byte{sep}, data...)
+		},
+		end: func(count int) []byte {
+			if count == 0 {
+				return []byte("[]")
+			}
+			return []byte("]")
+		},
+	})
+}
+
+// StreamSSE writes the items as Server-Sent Events. Plain items become
+// "message" events; a backend error is sent as an "error" event.
+func (g *Gin) StreamSSE(next StreamSource) error {
+	g.C.Header("Cache-Control", "no-cache")
+	g.C.Header("Connection", "keep-alive")
+	g.C.Header("X-Accel-Buffering", "no")
+	return g.stream("text/event-stream", next, streamWriter{
+		fail: func(response BaseResponse) []byte {
+			data, _ := json.Marshal(response)
+			event, _ := encodeSSE(SSEEvent{Event: "error", Data: json.RawMessage(data)})
+			return event
+		},
+		encode: func(item any) ([]byte, error) {
+			if event, ok := item.(SSEEvent); ok {
+				return encodeSSE(event)
+			}
+			data, err := streamItemJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			return encodeSSE(SSEEvent{Data: json.RawMessage(data)})
+		},
+	})
+}
+
+// streamWriter frames a stream format. Each hook returns the bytes to send,
+// written with a single Write so that writers allowing one write per call
+// (SafeSingleWrite) never see a frame split in two.
+type streamWriter struct {
+	// item frames the i-th encoded item; nil sends it as is.
+	item   func(i int, data []byte) []byte
+	end    func(count int) []byte
+	fail   func(response BaseResponse) []byte
+	encode func(item any) ([]byte, error)
+}
+
+// startStream switches a SafeResponseWriter, possibly wrapped by other
+// middleware, to SafeStreaming.
+func startStream(w gin.ResponseWriter) {
+	if s, ok := w.(interface{ Stream() }); ok {
+		s.Stream()
+	}
+}
+
+// stream pulls items from next and writes them as they arrive, flushing
+// after each one. An error before the first item is rendered as a normal
+// error response; once the stream started only the format's own error
+// marker can be written. It stops when the client disconnects.
+func (g *Gin) stream(contentType string, next StreamSource, sw streamWriter) error {
+	if g.C == nil {
+		Log.Error("Nil context in stream handler")
+		return errors.New("nil context")
+	}
+	if g.C.Writer.Written() {
+		g.log().Warn("Attempted duplicate response write", logger.String("path", g.C.FullPath()))
+		return errors.New("response already written")
+	}
+	if sw.encode == nil {
+		sw.encode = streamItemJSON
+	}
+
+	count := 0
+	for {
+		if err := g.disconnected("Client disconnected during stream"); err != nil {
+			return err
+		}
+		item, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if count == 0 {
+				g.Error(err)
+				return err
+			}
+			g.log().Warn("Stream failed", logger.Err(err), logger.Int("items", count), logger.String("path", g.C.FullPath()))
+			if sw.fail != nil {
+				g.C.Writer.Write(sw.fail(ToAPIError(err, http.StatusInternalServerError).Response()))
+				g.C.Writer.Flush()
+			}
+			return err
+		}
+
+		data, err := sw.encode(item)
+		if err != nil {
+			g.log().Error("Failed to encode stream item", logger.Err(err), logger.String("path", g.C.FullPath()))
+			if count == 0 {
+				g.C.AbortWithStatus(http.StatusInternalServerError)
+			}
+			return err
+		}
+		if count == 0 {
+			startStream(g.C.Writer)
+			g.C.Header("Content-Type", contentType)
+			g.C.Status(http.StatusOK)
+		}
+		if sw.item != nil {
+			data = sw.item(count, data)
+		}
+		if _, err := g.C.Writer.Write(data); err != nil {
+			g.log().Warn("Failed to write stream item", logger.Err(err), logger.String("path", g.C.FullPath()))
+			return err
+		}
+		g.C.Writer.Flush()
+		count++
+	}
+
+	if count == 0 {
+		startStream(g.C.Writer)
+		g.C.Header("Content-Type", contentType)
+		g.C.Status(http.StatusOK)
+	}
+	if sw.end != nil {
+		if _, err := g.C.Writer.Write(sw.end(count)); err != nil {
+			return err
+		}
+	}
+	g.C.Writer.Flush()
+	return nil
+}
+
+// disconnected logs and aborts when the client has gone away.
+func (g *Gin) disconnected(message string) error {
+	ctxErr := g.C.Request.Context().Err()
+	if ctxErr != nil {
+		g.log().Warn(message,
+			logger.String("path", g.C.FullPath()),
+			logger.Err(ctxErr),
+		)
+		g.C.Abort()
+	}
+	return ctxErr
+}
+
+// streamItemJSON encodes a stream item without decoding backend payloads:
+// the bytes of a gen.Response are passed through when they are valid JSON.
+func streamItemJSON(item any) ([]byte, error) {
+	var data []byte
+	switch v := item.(type) {
+	case interface{ GetData() []byte }:
+		data = v.GetData()
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		return json.Marshal(item)
+	}
+	if len(data) == 0 {
+		return []byte("null"), nil
+	}
+	if !json.Valid(data) {
+		return json.Marshal(string(data))
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeSSE(event SSEEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	if event.ID != "" {
+		buf.WriteString("id: " + sseLine(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		buf.WriteString("event: " + sseLine(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.Itoa(event.Retry) + "\n")
+	}
+
+	var data string
+	switch v := event.Data.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		encoded, err := streamItemJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		data = string(encoded)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// sseLine keeps a field value on one line.
+func sseLine(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,82 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/TechAlkurn/core/lib"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// AuthorizationMetadataKey carries "Bearer <jwt>", as the HTTP header does.
+const AuthorizationMetadataKey = "authorization"
+
+var ErrUnauthenticated = lib.NewError("UNAUTHENTICATED", codes.Unauthenticated, "authentication required")
+
+type userIDKey struct{}
+
+// UserID returns the id of the user authenticated by the auth interceptors.
+func UserID(ctx context.Context) (uint32, bool) {
+	id, ok := ctx.Value(userIDKey{}).(uint32)
+	return id, ok
+}
+
+func public(method string, methods []string) bool {
+	for _, m := range methods {
+		if m == method || (strings.HasSuffix(m, "/") && strings.HasPrefix(method, m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate validates the bearer token in the incoming metadata with
+// lib.VerifyJwt and stores the user id in the context. The global
+// lib.LoggedId storage is left alone, as it is shared by every call.
+func authenticate(ctx context.Context, method string, publicMethods []string) (context.Context, error) {
+	if public(method, publicMethods) {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AuthorizationMetadataKey)
+	if len(values) == 0 {
+		return nil, ErrUnauthenticated
+	}
+	id, err := lib.VerifyJwt(values[0])
+	if err != nil {
+		return nil, ErrUnauthenticated.Wrap(err)
+	}
+	return context.WithValue(ctx, userIDKey{}, id), nil
+}
+
+// UnaryServerAuth rejects calls without a valid token, except for the
+// public methods (see ServerConfig.Public).
+func UnaryServerAuth(publicMethods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, info.FullMethod, publicMethods)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerAuth(publicMethods ...string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), info.FullMethod, publicMethods)
+		if err != nil {
+			return err
+		}
+		return handler(srv, withContext(ss, ctx))
+	}
+}
+
+// WithToken forwards bearerToken ("Bearer <jwt>") on calls made with ctx.
+func WithToken(ctx context.Context, bearerToken string) context.Context {
+	if bearerToken == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, AuthorizationMetadataKey, bearerToken)
+}
@@ -0,0 +1,86 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TechAlkurn/core/lib"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuth(t *testing.T) {
+	t.Setenv("SECRET_KEY", "test-secret")
+	token, err := lib.GenerateJWT(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var userID uint32
+	handler := func(ctx context.Context) error {
+		userID, _ = UserID(ctx)
+		return nil
+	}
+	protected := newTestClient(t, ServerConfig{}, handler)
+	public := newTestClient(t, ServerConfig{Public: []string{"/grpc.health.v1.Health/"}}, handler)
+
+	lib.Clear()
+	for _, tt := range []struct {
+		name     string
+		token    string
+		noSecret bool
+		want     codes.Code
+	}{
+		{name: "missing token", want: codes.Unauthenticated},
+		{name: "valid token", token: "Bearer " + token, want: codes.OK},
+		{name: "forged token", token: "Bearer " + token + "x", want: codes.Unauthenticated},
+		{name: "not a bearer token", token: token, want: codes.Unauthenticated},
+		{name: "empty secret", token: "Bearer " + token, noSecret: true, want: codes.Unauthenticated},
+	} {
+		if tt.noSecret {
+			t.Setenv("SECRET_KEY", "")
+		}
+		userID = 0
+		err := check(protected, WithToken(context.Background(), tt.token))
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%s: got %v (%v), want %v", tt.name, got, err, tt.want)
+		}
+		if tt.want != codes.OK {
+			if !errors.Is(err, ErrUnauthenticated) {
+				t.Errorf("%s: got %v, want ErrUnauthenticated", tt.name, err)
+			}
+		} else if userID != 7 {
+			t.Errorf("%s: handler saw user %d", tt.name, userID)
+		}
+	}
+	if lib.Has("id") {
+		t.Error("authentication wrote the shared logged user storage")
+	}
+
+	userID = 0
+	if err := check(public, context.Background()); err != nil {
+		t.Errorf("public method: %v", err)
+	}
+	if userID != 0 {
+		t.Errorf("public method without token has user %d", userID)
+	}
+}
+
+func TestPublic(t *testing.T) {
+	for _, tt := range []struct {
+		method string
+		public []string
+		want   bool
+	}{
+		{checkMethod, nil, false},
+		{checkMethod, []string{checkMethod}, true},
+		{checkMethod, []string{"/grpc.health.v1.Health/"}, true},
+		{checkMethod, []string{"/grpc.health.v1.Health"}, false},
+		{"/grpc.health.v1.HealthX/Check", []string{"/grpc.health.v1.Health/"}, false},
+	} {
+		if got := public(tt.method, tt.public); got != tt.want {
+			t.Errorf("public(%q, %v) = %v, want %v", tt.method, tt.public, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/TechAlkurn/core/lib"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// ClientConfig configures DialOptions.
+type ClientConfig struct {
+	// Timeout applies to unary calls whose context has no deadline.
+	// Streams are left alone, as they are often meant to stay open.
+	Timeout time.Duration
+}
+
+// DialOptions chains the client interceptors: request id forwarding,
+// default deadline, logging and error normalization.
+//
+//	grpc.NewClient(addr, append(interceptor.DialOptions(interceptor.ClientConfig{Timeout: 5 * time.Second}), creds)...)
+func DialOptions(cfg ClientConfig) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(
+			lib.RequestIDUnaryClientInterceptor(),
+			UnaryClientErrors(),
+			UnaryClientLogging(),
+			UnaryClientDeadline(cfg.Timeout),
+		),
+		grpc.WithChainStreamInterceptor(
+			lib.RequestIDStreamClientInterceptor(),
+			StreamClientErrors(),
+			StreamClientLogging(),
+		),
+	}
+}
+
+// fromStatus turns status errors into *lib.Error, so callers can match them
+// with errors.Is against their lib.Error sentinels. The status details are
+// kept, so field violations and retry delays still reach action.Error.
+// io.EOF and other errors that are not statuses pass through.
+func fromStatus(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	if st, ok := status.FromError(err); ok {
+		return lib.FromStatus(st)
+	}
+	return err
+}
+
+func UnaryClientErrors() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return fromStatus(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	return fromStatus(s.ClientStream.SendMsg(m))
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	return fromStatus(s.ClientStream.RecvMsg(m))
+}
+
+func StreamClientErrors() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, fromStatus(err)
+		}
+		return &clientStream{ClientStream: cs}, nil
+	}
+}
+
+func UnaryClientLogging() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		logCall(ctx, "Call made", method, start, err)
+		return err
+	}
+}
+
+// StreamClientLogging logs failures to open a stream; errors on the open
+// stream are the caller's to handle.
+func StreamClientLogging() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logCall(ctx, "Stream failed", method, start, err)
+		}
+		return cs, err
+	}
+}
+
+// UnaryClientDeadline gives calls without a deadline the timeout.
+func UnaryClientDeadline(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := withDeadline(ctx, timeout, 0)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
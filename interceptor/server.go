@@ -0,0 +1,260 @@
+// Package interceptor holds the gRPC interceptors shared by our services and
+// their clients: request ids, logging, panic recovery, JWT auth, deadlines
+// and error normalization. Most servers only need the bundle:
+//
+//	grpc.NewServer(interceptor.ServerOptions(interceptor.ServerConfig{
+//		Public:     []string{"/auth.Auth/"},
+//		MaxTimeout: 30 * time.Second,
+//	})...)
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/TechAlkurn/core/lib"
+	"github.com/TechAlkurn/core/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var Log = logger.For("interceptor")
+
+// ServerConfig configures ServerOptions.
+type ServerConfig struct {
+	// Public lists methods served without a token. An entry ending in "/"
+	// covers a whole service, e.g. "/auth.Auth/".
+	Public []string
+	// NoAuth disables the JWT check altogether.
+	NoAuth bool
+	// DefaultTimeout applies to calls arriving without a deadline.
+	DefaultTimeout time.Duration
+	// MaxTimeout caps the deadline a client may ask for.
+	MaxTimeout time.Duration
+}
+
+// ServerOptions chains every server interceptor in the order they depend
+// on each other: request id, error normalization, logging, recovery,
+// deadline and auth.
+func ServerOptions(cfg ServerConfig) []grpc.ServerOption {
+	unary := []grpc.UnaryServerInterceptor{
+		UnaryServerRequestID(),
+		UnaryServerErrors(),
+		UnaryServerLogging(),
+		UnaryServerRecovery(),
+		UnaryServerDeadline(cfg.DefaultTimeout, cfg.MaxTimeout),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		StreamServerRequestID(),
+		StreamServerErrors(),
+		StreamServerLogging(),
+		StreamServerRecovery(),
+		StreamServerDeadline(cfg.DefaultTimeout, cfg.MaxTimeout),
+	}
+	if !cfg.NoAuth {
+		unary = append(unary, UnaryServerAuth(cfg.Public...))
+		stream = append(stream, StreamServerAuth(cfg.Public...))
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// serverStream replaces the context of a wrapped stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func withContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
+// requestID takes a valid id from the incoming metadata or generates one,
+// and sends it back in the response header.
+func requestID(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(lib.RequestIDMetadataKey); len(values) > 0 && lib.ValidRequestID(values[0]) {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = lib.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(lib.RequestIDMetadataKey, id))
+	return lib.WithRequestID(ctx, id)
+}
+
+func UnaryServerRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(requestID(ctx), req)
+	}
+}
+
+func StreamServerRequestID() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, withContext(ss, requestID(ss.Context())))
+	}
+}
+
+// toError is lib.AsError that also keeps context errors apart from
+// internal ones.
+func toError(err error) *lib.Error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return lib.ErrCanceled.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return lib.ErrDeadlineExceeded.Wrap(err)
+	}
+	return lib.AsError(err)
+}
+
+// normalize makes sure clients never get the text of an unexpected error.
+// A lib.Error or context error becomes its status with an ErrorInfo detail,
+// a status with a known code passes unchanged with its details (BadRequest,
+// RetryInfo...), and anything else becomes lib.ErrInternal.
+func normalize(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *lib.Error
+	if !errors.As(err, &e) {
+		if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+			return st.Err()
+		}
+	}
+	return toError(err).GRPCStatus().Err()
+}
+
+func UnaryServerErrors() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, normalize(err)
+	}
+}
+
+func StreamServerErrors() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return normalize(handler(srv, ss))
+	}
+}
+
+// logCall writes one entry per call: server faults at error level with the
+// underlying error, client errors at warn level and the rest at info.
+func logCall(ctx context.Context, msg string, method string, start time.Time, err error) {
+	l := Log
+	if id := lib.RequestIDFromContext(ctx); id != "" {
+		l = l.With(logger.String("request_id", id))
+	}
+	code := codes.OK
+	if err != nil {
+		code = toError(err).GRPCCode
+	}
+	fields := []logger.Field{
+		logger.String("method", method),
+		logger.String("code", code.String()),
+		logger.Duration("duration", time.Since(start)),
+	}
+	switch {
+	case err == nil:
+		l.Info(msg, fields...)
+	case lib.HTTPStatusFromCode(code) >= 500:
+		l.Error(msg, append(fields, logger.Err(err))...)
+	default:
+		l.Warn(msg, append(fields, logger.Err(err))...)
+	}
+}
+
+func UnaryServerLogging() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, "Call handled", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamServerLogging() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), "Stream handled", info.FullMethod, start, err)
+		return err
+	}
+}
+
+// recovered logs a panic with its stack and returns it as lib.ErrInternal.
+func recovered(ctx context.Context, method string, value any) error {
+	l := Log
+	if id := lib.RequestIDFromContext(ctx); id != "" {
+		l = l.With(logger.String("request_id", id))
+	}
+	l.Error("Panic recovered",
+		logger.String("panic", fmt.Sprint(value)),
+		logger.String("method", method),
+		logger.ByteString("stack", debug.Stack()),
+	)
+	return lib.ErrInternal.Wrap(fmt.Errorf("panic: %v", value))
+}
+
+func UnaryServerRecovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				resp, err = nil, recovered(ctx, info.FullMethod, value)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerRecovery() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				err = recovered(ss.Context(), info.FullMethod, value)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// withDeadline applies def to calls without a deadline and caps the others
+// at limit. Zero disables either.
+func withDeadline(ctx context.Context, def time.Duration, limit time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	switch {
+	case !ok && def > 0:
+		return context.WithTimeout(ctx, def)
+	case limit > 0 && (!ok || time.Until(deadline) > limit):
+		return context.WithTimeout(ctx, limit)
+	}
+	return ctx, func() {}
+}
+
+func UnaryServerDeadline(def time.Duration, limit time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel := withDeadline(ctx, def, limit)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerDeadline(def time.Duration, limit time.Duration) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := withDeadline(ss.Context(), def, limit)
+		defer cancel()
+		return handler(srv, withContext(ss, ctx))
+	}
+}
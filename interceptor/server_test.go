@@ -0,0 +1,196 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechAlkurn/core/lib"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const checkMethod = "/grpc.health.v1.Health/Check"
+
+// healthServer answers Check with check.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	check func(ctx context.Context) error
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// newTestClient serves check over bufconn with the interceptors of cfg and
+// returns a client using DialOptions.
+func newTestClient(t *testing.T, cfg ServerConfig, check func(ctx context.Context) error) healthpb.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(ServerOptions(cfg)...)
+	healthpb.RegisterHealthServer(srv, &healthServer{check: check})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn", append(DialOptions(ClientConfig{}),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func check(client healthpb.HealthClient, ctx context.Context, opts ...grpc.CallOption) error {
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, opts...)
+	return err
+}
+
+func TestRecovery(t *testing.T) {
+	client := newTestClient(t, ServerConfig{NoAuth: true}, func(ctx context.Context) error {
+		panic("boom")
+	})
+	err := check(client, context.Background())
+	if !errors.Is(err, lib.ErrInternal) || status.Code(err) != codes.Internal {
+		t.Fatalf("got %v, want lib.ErrInternal", err)
+	}
+	if strings.Contains(err.Error(), "boom") {
+		t.Errorf("panic value leaked to the client: %v", err)
+	}
+}
+
+func TestRequestIDRoundTrip(t *testing.T) {
+	var got string
+	client := newTestClient(t, ServerConfig{NoAuth: true}, func(ctx context.Context) error {
+		got = lib.RequestIDFromContext(ctx)
+		return nil
+	})
+
+	var header metadata.MD
+	ctx := lib.WithRequestID(context.Background(), "req-42")
+	if err := check(client, ctx, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got != "req-42" {
+		t.Errorf("handler saw request id %q", got)
+	}
+	if values := header.Get(lib.RequestIDMetadataKey); len(values) != 1 || values[0] != "req-42" {
+		t.Errorf("response header = %v", values)
+	}
+
+	header = nil
+	if err := check(client, context.Background(), grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if values := header.Get(lib.RequestIDMetadataKey); len(values) != 1 || values[0] != got || !lib.ValidRequestID(got) {
+		t.Errorf("generated id %q, header %v", got, values)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	var remaining time.Duration
+	var ok bool
+	client := newTestClient(t, ServerConfig{NoAuth: true, DefaultTimeout: 2 * time.Second, MaxTimeout: 5 * time.Second}, func(ctx context.Context) error {
+		var deadline time.Time
+		deadline, ok = ctx.Deadline()
+		remaining = time.Until(deadline)
+		return nil
+	})
+
+	for _, tt := range []struct {
+		name     string
+		timeout  time.Duration
+		min, max time.Duration
+	}{
+		{"none gets the default", 0, time.Second, 2 * time.Second},
+		{"long is capped", time.Hour, 4 * time.Second, 5 * time.Second},
+		{"short is kept", time.Second, 0, time.Second},
+	} {
+		ctx := context.Background()
+		if tt.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+			defer cancel()
+		}
+		if err := check(client, ctx); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !ok || remaining <= tt.min || remaining > tt.max {
+			t.Errorf("%s: deadline in %v (set %v)", tt.name, remaining, ok)
+		}
+	}
+}
+
+func TestErrorNormalization(t *testing.T) {
+	errNotFound := lib.NewError("USER_NOT_FOUND", codes.NotFound, "User {id} not found")
+	invalid, err := status.New(codes.InvalidArgument, "invalid").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "email", Description: "is required"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handlerErr error
+	client := newTestClient(t, ServerConfig{NoAuth: true}, func(ctx context.Context) error {
+		return handlerErr
+	})
+
+	t.Run("lib.Error", func(t *testing.T) {
+		handlerErr = errNotFound.With("id", 7)
+		err := check(client, context.Background())
+		var e *lib.Error
+		if !errors.Is(err, errNotFound) || !errors.As(err, &e) {
+			t.Fatalf("got %v, want %v", err, errNotFound)
+		}
+		if e.Text() != "User 7 not found" || e.Details["id"] != "7" {
+			t.Errorf("got %q with %v", e.Text(), e.Details)
+		}
+	})
+
+	t.Run("status details survive", func(t *testing.T) {
+		handlerErr = invalid.Err()
+		err := check(client, context.Background())
+		st, _ := status.FromError(err)
+		if st.Code() != codes.InvalidArgument {
+			t.Fatalf("code = %v", st.Code())
+		}
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*errdetails.BadRequest); ok && d.GetFieldViolations()[0].GetField() == "email" {
+				return
+			}
+		}
+		t.Errorf("BadRequest detail lost: %v", st.Details())
+	})
+
+	t.Run("plain errors are hidden", func(t *testing.T) {
+		handlerErr = errors.New("pq: connection refused")
+		err := check(client, context.Background())
+		if !errors.Is(err, lib.ErrInternal) || strings.Contains(err.Error(), "pq:") {
+			t.Errorf("got %v, want a bare lib.ErrInternal", err)
+		}
+	})
+
+	t.Run("unknown statuses are hidden", func(t *testing.T) {
+		handlerErr = status.Error(codes.Unknown, "stack trace")
+		err := check(client, context.Background())
+		if !errors.Is(err, lib.ErrInternal) || strings.Contains(err.Error(), "stack trace") {
+			t.Errorf("got %v, want a bare lib.ErrInternal", err)
+		}
+	})
+}
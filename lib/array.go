@@ -1,10 +1,7 @@
 package lib
 
 import (
-	"fmt"
-	"net/url"
 	"sort"
-	"strconv"
 	"strings"
 )
 
@@ -241,7 +238,7 @@ func Ksort(elements map[any]any) map[any]any {
 	for key := range elements {
 		strKey, ok := key.(string)
 		if !ok {
-			fmt.Println("All keys must be strings.")
+			l.Warn("Ksort: all keys must be strings")
 			return nil
 		}
 		keys = append(keys, strKey)
@@ -281,21 +278,8 @@ func SelectionNotification(selection string) []uint32 {
 	return sel
 }
 
+// MapToURLParams encodes data as a query string; nested maps and slices use
+// bracket notation (see NestedURLValues).
 func MapToURLParams(data map[string]any) string {
-	params := url.Values{}
-	for key, value := range data {
-		switch v := value.(type) {
-		case string:
-			params.Set(key, v)
-		case int:
-			params.Set(key, strconv.Itoa(v))
-		case bool:
-			params.Set(key, strconv.FormatBool(v))
-		case float64:
-			params.Set(key, fmt.Sprintf("%f", v))
-		default:
-			params.Set(key, fmt.Sprintf("%v", v))
-		}
-	}
-	return params.Encode()
+	return NestedURLValues(data).Encode()
 }
@@ -1,7 +1,8 @@
 package lib
 
 import (
-	"os"
+	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -81,42 +82,47 @@ func ValidationAuthclient(source, sourceId string) bool {
 	return true
 }
 
-func Pluck(slice interface{}, fieldName string) []interface{} {
-	// Ensure the input is a slice
+var (
+	ErrPluckNotSlice  = errors.New("pluck: first argument must be a slice")
+	ErrPluckNotStruct = errors.New("pluck: slice elements must be structs or pointers to structs")
+	ErrPluckNoField   = errors.New("pluck: field does not exist in struct")
+)
+
+// PluckField collects fieldName from every struct (or struct pointer) in slice.
+func PluckField(slice any, fieldName string) ([]any, error) {
 	sliceValue := reflect.ValueOf(slice)
 	if sliceValue.Kind() != reflect.Slice {
-		panic("Pluck: first argument must be a slice")
+		return nil, ErrPluckNotSlice
 	}
-	// Prepare a result slice
-	result := make([]interface{}, 0, sliceValue.Len())
-	// Iterate over the slice
+	result := make([]any, 0, sliceValue.Len())
 	for i := 0; i < sliceValue.Len(); i++ {
-		// Get the element of the slice
 		item := sliceValue.Index(i)
-		// Ensure the element is a struct or a pointer to a struct
 		if item.Kind() == reflect.Ptr {
 			item = item.Elem()
 		}
 		if item.Kind() != reflect.Struct {
-			panic("Pluck: slice elements must be structs or pointers to structs")
+			return nil, ErrPluckNotStruct
 		}
-		// Extract the field value
 		fieldValue := item.FieldByName(fieldName)
 		if !fieldValue.IsValid() {
-			panic("Pluck: field '" + fieldName + "' does not exist in struct")
+			return nil, fmt.Errorf("%w: %s", ErrPluckNoField, fieldName)
 		}
-		// Append the field value to the result
 		result = append(result, fieldValue.Interface())
 	}
+	return result, nil
+}
 
+// Deprecated: use PluckField. Pluck panics on the inputs PluckField rejects.
+func Pluck(slice interface{}, fieldName string) []interface{} {
+	result, err := PluckField(slice, fieldName)
+	if err != nil {
+		panic(err)
+	}
 	return result
 }
 
 func DefaultEnv(key, val string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return val
+	return Env.GetDefault(key, val)
 }
 
 func Wrap[T any](fn func() (T, error)) func() (any, error) {
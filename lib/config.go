@@ -0,0 +1,499 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret is a configuration value that never prints its content. Use Reveal
+// to obtain the underlying string.
+type Secret string
+
+const redacted = "******"
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.String())), nil
+}
+
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// MissingConfigError lists the required keys that have no value.
+type MissingConfigError struct {
+	Keys []string
+}
+
+func (e *MissingConfigError) Error() string {
+	return "missing required configuration: " + strings.Join(e.Keys, ", ")
+}
+
+// Config resolves settings from, in order of precedence: the process
+// environment, files referenced by <KEY>_FILE (mounted secrets), and .env
+// files passed to LoadEnvFile.
+type Config struct {
+	mu       sync.RWMutex
+	files    []string
+	values   map[string]string
+	required map[string]struct{}
+	secrets  map[string]struct{}
+	onReload []func(*Config)
+	modTimes map[string]time.Time
+	// loadErr is the error of the last load. Watch retries a failed load
+	// and Validate reports it.
+	loadErr error
+}
+
+func NewConfig() *Config {
+	c := &Config{
+		values:   make(map[string]string),
+		required: make(map[string]struct{}),
+		secrets:  make(map[string]struct{}),
+		modTimes: make(map[string]time.Time),
+	}
+	c.load()
+	return c
+}
+
+// Env is the process wide configuration used throughout lib.
+var Env = NewConfig()
+
+// LoadEnvFile adds .env files to the configuration and reloads it. Missing
+// files are skipped so the same call works in every environment.
+func (c *Config) LoadEnvFile(paths ...string) error {
+	c.mu.Lock()
+	c.files = append(c.files, paths...)
+	c.mu.Unlock()
+	return c.Reload()
+}
+
+// Require registers keys that Validate must find.
+func (c *Config) Require(keys ...string) *Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.required[key] = struct{}{}
+	}
+	return c
+}
+
+// MarkSecret registers keys whose values are redacted by Redacted. Keys whose
+// name contains SECRET, PASSWORD, TOKEN or KEY are treated as secret already.
+func (c *Config) MarkSecret(keys ...string) *Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.secrets[key] = struct{}{}
+	}
+	return c
+}
+
+// OnReload registers fn to run after every successful Reload.
+func (c *Config) OnReload(fn func(*Config)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReload = append(c.onReload, fn)
+}
+
+func (c *Config) load() error {
+	c.mu.Lock()
+	values := make(map[string]string)
+	modTimes := make(map[string]time.Time)
+	var errs []error
+	for _, path := range c.files {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		modTimes[path] = info.ModTime()
+		parsed, err := parseEnvFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for key, value := range parsed {
+			values[key] = value
+		}
+	}
+
+	// Resolve <KEY>_FILE references from both .env files and the environment.
+	refs := make(map[string]string)
+	for key, value := range values {
+		if strings.HasSuffix(key, "_FILE") {
+			refs[key] = value
+		}
+	}
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.HasSuffix(key, "_FILE") {
+			refs[key] = value
+		}
+	}
+	for ref, path := range refs {
+		key := strings.TrimSuffix(ref, "_FILE")
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ref, err))
+			// Keep serving the secret we had rather than an empty one.
+			if previous, ok := c.values[key]; ok {
+				values[key] = previous
+			}
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+		values[key] = strings.TrimRight(string(content), "\r\n")
+		c.secrets[key] = struct{}{}
+	}
+	// A source that failed to load may have held any key, so keep what it
+	// used to provide until a later load succeeds.
+	if len(errs) > 0 {
+		for key, value := range c.values {
+			if _, ok := values[key]; !ok {
+				values[key] = value
+			}
+		}
+	}
+
+	c.values = values
+	c.modTimes = modTimes
+	c.loadErr = errors.Join(errs...)
+	c.mu.Unlock()
+	return c.loadErr
+}
+
+// Reload re-reads .env and secret files, then runs the OnReload hooks.
+func (c *Config) Reload() error {
+	if err := c.load(); err != nil {
+		return err
+	}
+	c.mu.RLock()
+	hooks := append([]func(*Config){}, c.onReload...)
+	c.mu.RUnlock()
+	for _, fn := range hooks {
+		fn(c)
+	}
+	return nil
+}
+
+// defaultWatchInterval replaces a Watch interval that is not positive.
+const defaultWatchInterval = 30 * time.Second
+
+// Watch polls the loaded files every interval and reloads when one changes,
+// until ctx is done. Reload errors are passed to LogError. An interval of
+// zero or less polls every 30 seconds.
+func (c *Config) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.changed() {
+				LogError(c.Reload())
+			}
+		}
+	}
+}
+
+func (c *Config) changed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.loadErr != nil {
+		return true
+	}
+	for _, path := range c.files {
+		if _, seen := c.modTimes[path]; !seen && FileIsExist(path) {
+			return true
+		}
+	}
+	for path, modTime := range c.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns a *MissingConfigError when a required key is empty,
+// joined with the error of the last load, e.g. a *_FILE secret that could
+// not be read at startup.
+func (c *Config) Validate() error {
+	c.mu.RLock()
+	keys := make([]string, 0, len(c.required))
+	for key := range c.required {
+		keys = append(keys, key)
+	}
+	loadErr := c.loadErr
+	c.mu.RUnlock()
+
+	var missing []string
+	for _, key := range keys {
+		if c.Get(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.Join(&MissingConfigError{Keys: missing}, loadErr)
+	}
+	return loadErr
+}
+
+func (c *Config) Lookup(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.values[key]
+	return value, ok
+}
+
+func (c *Config) Get(key string) string {
+	value, _ := c.Lookup(key)
+	return value
+}
+
+func (c *Config) GetDefault(key string, val string) string {
+	if value := c.Get(key); value != "" {
+		return value
+	}
+	return val
+}
+
+func (c *Config) Bytes(key string) []byte {
+	return []byte(c.Get(key))
+}
+
+func (c *Config) Secret(key string) Secret {
+	return Secret(c.Get(key))
+}
+
+func (c *Config) Int(key string, val int) int {
+	if value, err := strconv.Atoi(c.Get(key)); err == nil {
+		return value
+	}
+	return val
+}
+
+func (c *Config) Bool(key string, val bool) bool {
+	if value, err := strconv.ParseBool(c.Get(key)); err == nil {
+		return value
+	}
+	return val
+}
+
+func (c *Config) Duration(key string, val time.Duration) time.Duration {
+	if value, err := time.ParseDuration(c.Get(key)); err == nil {
+		return value
+	}
+	return val
+}
+
+func (c *Config) isSecret(key string) bool {
+	if _, ok := c.secrets[key]; ok {
+		return true
+	}
+	upper := strings.ToUpper(key)
+	for _, word := range []string{"SECRET", "PASSWORD", "TOKEN", "KEY"} {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns the keys loaded from files plus the required keys, with
+// secret values masked, for startup logging.
+func (c *Config) Redacted() map[string]string {
+	c.mu.RLock()
+	keys := make([]string, 0, len(c.values)+len(c.required))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	for key := range c.required {
+		keys = append(keys, key)
+	}
+	c.mu.RUnlock()
+
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value := c.Get(key)
+		c.mu.RLock()
+		secret := c.isSecret(key)
+		c.mu.RUnlock()
+		if secret && value != "" {
+			value = redacted
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// Unmarshal fills the struct pointed to by target from `env` tags:
+//
+//	SecretKey lib.Secret      `env:"SECRET_KEY,required"`
+//	TokenTTL  time.Duration   `env:"TOKEN_TTL" default:"24h"`
+//	Origins   []string        `env:"CORS_ORIGINS"`
+func (c *Config) Unmarshal(target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config target must be a pointer to a struct")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	var missing []string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := c.Get(name)
+		if value == "" {
+			value = field.Tag.Get("default")
+		}
+		if value == "" {
+			if strings.Contains(opts, "required") {
+				missing = append(missing, name)
+			}
+			continue
+		}
+		if err := setConfigField(rv.Field(i), value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingConfigError{Keys: missing}
+	}
+	return nil
+}
+
+func setConfigField(v reflect.Value, value string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(value))
+			return nil
+		}
+		parts := strings.Split(value, ",")
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setConfigField(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+// parseEnvFile reads KEY=VALUE lines, ignoring blank lines, # comments and an
+// optional "export " prefix. Values may be single or double quoted.
+func parseEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+			if value[0] == '"' {
+				if unquoted, err := strconv.Unquote(value); err == nil {
+					value = unquoted
+				} else {
+					value = value[1 : n-1]
+				}
+			} else {
+				value = value[1 : n-1]
+			}
+		} else if idx := strings.Index(value, " #"); idx != -1 {
+			value = strings.TrimSpace(value[:idx])
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
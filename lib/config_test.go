@@ -0,0 +1,71 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigReloadKeepsSecretOnError(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secretPath, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("TEST_RELOAD_KEY_FILE="+secretPath+"\nTEST_RELOAD_NAME=app\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig()
+	if err := c.LoadEnvFile(envPath); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Get("TEST_RELOAD_KEY"); got != "s3cret" {
+		t.Fatalf("initial load: got %q", got)
+	}
+
+	if err := os.Remove(secretPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err == nil {
+		t.Fatal("Reload with a missing secret file should fail")
+	}
+	if got := c.Get("TEST_RELOAD_KEY"); got != "s3cret" {
+		t.Errorf("after failed reload: got %q, want the previous secret", got)
+	}
+	if got := c.Get("TEST_RELOAD_NAME"); got != "app" {
+		t.Errorf("after failed reload: got %q for a plain key", got)
+	}
+	if !c.changed() {
+		t.Error("a failed source should be retried by Watch")
+	}
+}
+
+func TestConfigValidateReportsLoadError(t *testing.T) {
+	t.Setenv("TEST_STARTUP_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	c := NewConfig().Require("TEST_STARTUP_NAME")
+	err := c.Validate()
+	var missing *MissingConfigError
+	if !errors.As(err, &missing) || len(missing.Keys) != 1 {
+		t.Errorf("got %v, want the missing key", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, want the unreadable secret file", err)
+	}
+
+	t.Setenv("TEST_STARTUP_NAME", "app")
+	if err := c.Validate(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, want the unreadable secret file alone", err)
+	}
+}
+
+func TestConfigWatchZeroInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewConfig().Watch(ctx, 0)
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"maps"
 	"regexp"
 	"strconv"
@@ -72,11 +71,23 @@ func IsNumeric(s any) bool {
 	return regexp.MustCompile(`\d`).MatchString(str)
 }
 
+var ErrInvalidBool = errors.New("invalid boolean")
+
+// ParseBool accepts the values strconv.ParseBool does, in any type that
+// prints as one of them.
+func ParseBool(s any) (bool, error) {
+	b, err := strconv.ParseBool(fmt.Sprintf("%v", s))
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidBool, s)
+	}
+	return b, nil
+}
+
+// Deprecated: use ParseBool. ToBool panics on values that are not booleans.
 func ToBool(s any) bool {
-	str := fmt.Sprintf("%v", s)
-	b, err := strconv.ParseBool(str)
+	b, err := ParseBool(s)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	return b
 }
@@ -2,32 +2,47 @@ package lib
 
 import (
 	"context"
+	"os"
 
-	"github.com/dimk00z/grpc-filetransfer/pkg/logger"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/TechAlkurn/core/logger"
 )
 
-var l *logger.Logger
+var l = logger.For("lib")
 
-func init() {
-	// Assuming your logger has a New function that initializes a logger
-	l = logger.New("debug")
+func LogError(err error) error {
+	if err != nil {
+		l.Error(err.Error())
+	}
+	return err
 }
 
-func LogError(err error) error {
+// LogErrorContext is LogError tagged with the request id in ctx.
+func LogErrorContext(ctx context.Context, err error) error {
 	if err != nil {
-		l.Error(err)
+		if id := RequestIDFromContext(ctx); id != "" {
+			l.Error(err.Error(), logger.String("request_id", id))
+			return err
+		}
+		l.Error(err.Error())
 	}
 	return err
 }
 
+// fatal logs msg with err and exits, for the helpers that have always
+// treated their errors as fatal.
+func fatal(msg string, err error) {
+	l.Error(msg, logger.Err(err))
+	os.Exit(1)
+}
+
+// ContextError returns ErrCanceled or ErrDeadlineExceeded, logged, once ctx
+// is done.
 func ContextError(ctx context.Context) error {
 	switch ctx.Err() {
 	case context.Canceled:
-		return LogError(status.Error(codes.Canceled, "request is canceled"))
+		return LogErrorContext(ctx, ErrCanceled.Wrap(ctx.Err()))
 	case context.DeadlineExceeded:
-		return LogError(status.Error(codes.DeadlineExceeded, "deadline is exceeded"))
+		return LogErrorContext(ctx, ErrDeadlineExceeded.Wrap(ctx.Err()))
 	default:
 		return nil
 	}
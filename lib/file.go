@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 )
 
@@ -25,48 +25,82 @@ func DirIsExist(path string) bool {
 	return !os.IsNotExist(err)
 }
 
-func WriteCsv(fileName string, header []string, data [][]any) bool {
+var (
+	ErrFileCreate  = errors.New("cannot create file")
+	ErrFileRead    = errors.New("cannot read file")
+	ErrFileWrite   = errors.New("cannot write file")
+	ErrInvalidJSON = errors.New("invalid JSON")
+)
+
+// SaveCsv writes header and data to fileName, replacing any existing file.
+func SaveCsv(fileName string, header []string, data [][]any) error {
 	csvFile, err := os.Create(fileName)
 	if err != nil {
-		log.Fatalf("failed to create file: %s", err)
+		return fmt.Errorf("%w: %w", ErrFileCreate, err)
 	}
+	defer csvFile.Close()
 
-	csvwriter := csv.NewWriter(csvFile)
-	defer csvFile.Close()   // Close the file when the function exits
-	defer csvwriter.Flush() // Flush the writer when the function exits
-
-	csvwriter.Write(header)
-	var entries [][]string
-
+	entries := make([][]string, 0, len(data)+1)
+	entries = append(entries, header)
 	for _, entry := range data {
-		var data []string
+		var row []string
 		for _, entity := range entry {
-			data = append(data, fmt.Sprintf("%v", entity))
+			row = append(row, fmt.Sprintf("%v", entity))
 		}
-		entries = append(entries, data)
+		entries = append(entries, row)
+	}
+	// WriteAll flushes and reports the first write error.
+	if err := csv.NewWriter(csvFile).WriteAll(entries); err != nil {
+		return fmt.Errorf("%w: %w", ErrFileWrite, err)
 	}
-	if err := csvwriter.WriteAll(entries); err != nil {
-		log.Fatalf("failed to write file: %s", err)
+	if err := csvFile.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrFileWrite, err)
 	}
+	return nil
+}
 
+// Deprecated: use SaveCsv. WriteCsv panics when the file cannot be written.
+func WriteCsv(fileName string, header []string, data [][]any) bool {
+	if err := SaveCsv(fileName, header, data); err != nil {
+		panic(err)
+	}
 	return true
 }
 
 func ReadCsv(fileName string) ([][]string, error) {
 	file, err := os.Open(fileName)
 	if err != nil {
-		log.Fatalf("failed to reading file: %s", err)
+		return nil, fmt.Errorf("%w: %w", ErrFileRead, err)
 	}
-	return csv.NewReader(file).ReadAll()
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFileRead, err)
+	}
+	return records, nil
 }
 
-func WriteFile(fileName string, buf *bytes.Buffer) {
+// SaveFile writes buf to fileName, replacing any existing file.
+func SaveFile(fileName string, buf *bytes.Buffer) error {
 	file, err := os.Create(fileName)
 	if err != nil {
-		log.Fatalf("failed to reading file: %s", err)
+		return fmt.Errorf("%w: %w", ErrFileCreate, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("%w: %w", ErrFileWrite, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrFileWrite, err)
+	}
+	return nil
+}
+
+// Deprecated: use SaveFile. WriteFile panics when the file cannot be written.
+func WriteFile(fileName string, buf *bytes.Buffer) {
+	if err := SaveFile(fileName, buf); err != nil {
+		panic(err)
 	}
-	file.Write(buf.Bytes())
-	defer file.Close() // Close the file when the function exits
 }
 
 func DeleteFile(fileName string) error {
@@ -79,24 +113,18 @@ func DeleteFile(fileName string) error {
 func ReadJSONFile(fileName string) (data any, err error) {
 	jsonData, err := os.ReadFile(fileName)
 	if err != nil {
-		log.Fatalf("Error reading JSON file: %v", err)
+		return nil, fmt.Errorf("%w: %w", ErrFileRead, err)
 	}
-	// Define a variable to store the decoded JSON data
-	// Unmarshal the JSON data into the struct
-	err = json.Unmarshal(jsonData, &data)
-	if err != nil {
-		log.Fatalf("Error unmarshalling JSON: %v", err)
+	if err := json.Unmarshal(jsonData, &data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
 	}
-	return
+	return data, nil
 }
 
 func ReadContentFile(fileName string) (content string, err error) {
 	data, err := os.ReadFile(fileName)
 	if err != nil {
-		log.Fatalf("Error reading JSON file: %v", err)
+		return "", fmt.Errorf("%w: %w", ErrFileRead, err)
 	}
-	// Define a variable to store the decoded JSON data
-	// Unmarshal the JSON data into the struct
-	content = string(data)
-	return
+	return string(data), nil
 }
@@ -107,7 +107,9 @@ func (event *Event) Generate(prodId string) (string, error) {
 	if err := icsTmpl.Execute(buf, obj); err != nil {
 		return "", err
 	}
-	lib.WriteFile(prodId, buf)
+	if err := lib.SaveFile(prodId, buf); err != nil {
+		return "", err
+	}
 	return buf.String(), nil
 }
 
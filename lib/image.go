@@ -12,10 +12,10 @@ import (
 )
 
 func Thumb(file string, width int, height int) string {
-	env_cache := os.Getenv("cache")
-	env_image := os.Getenv("image")
-	env_storage := os.Getenv("storage")
-	storage_path := os.Getenv("storagePath")
+	env_cache := Env.Get("cache")
+	env_image := Env.Get("image")
+	env_storage := Env.Get("storage")
+	storage_path := Env.Get("storagePath")
 
 	thumbs := strings.Split(file, ".")
 	thumb := fmt.Sprintf("%s-%d-%d.%s", thumbs[0], width, height, thumbs[len(thumbs)-1])
@@ -69,7 +69,7 @@ func Thumb(file string, width int, height int) string {
 func ThumbS3(originalURL string, width int, height int) (string, string) {
 	originalURL = fmt.Sprintf("uploads/storage/%s", originalURL)
 	cacheKey := generateCacheKey(originalURL, width, height)
-	thumb := fmt.Sprintf("%s/%s", os.Getenv("S3_CDN_ENDPOINT"), cacheKey)
+	thumb := fmt.Sprintf("%s/%s", Env.Get("S3_CDN_ENDPOINT"), cacheKey)
 	return cacheKey, thumb
 }
 
@@ -146,7 +146,7 @@ func _thumbS3(originalURL string, width int, height int) string {
 func isCached(cacheKey string) bool {
 	// Check if the resized image is already cached in DigitalOcean Spaces
 	// DigitalOcean Spaces bucket name
-	bucketName := os.Getenv("S3_BUCKET")
+	bucketName := Env.Get("S3_BUCKET")
 	//	config.Log.Println("BUCKET cache URL:", cacheKey)
 	// result, err := config.AwsS3.GetObject(&s3.GetObjectInput{Bucket: aws.String(bucketName), Key: aws.String(cacheKey)})
 	// if err != nil {
@@ -163,8 +163,8 @@ func isCached(cacheKey string) bool {
 
 func downloadFromS3(objectURL string) ([]byte, error) {
 	// Download the original image from DigitalOcean Spaces
-	bucketName := os.Getenv("S3_BUCKET")
-	// cdn_endpoint := os.Getenv("S3_CDN_ENDPOINT")
+	bucketName := Env.Get("S3_BUCKET")
+	// cdn_endpoint := Env.Get("S3_CDN_ENDPOINT")
 	// objectURL = strings.TrimPrefix(objectURL, cdn_endpoint)
 	// objectURL = strings.TrimPrefix(objectURL, "/")
 	// config.Log.Println("objectURL:", objectURL)
@@ -189,7 +189,7 @@ func uploadToS3(fileName string, buffer *bytes.Buffer) (err error) {
 	// Upload the resized image to DigitalOcean Spaces
 	// fileName = ChunkSplit(Substr(fileName, 0, 8), 1, "/")
 	// config.Log.Println("file name:", fileName)
-	bucketName := os.Getenv("S3_BUCKET")
+	bucketName := Env.Get("S3_BUCKET")
 	_, err = config.AwsS3.PutObject(&s3.PutObjectInput{
 		Bucket:        aws.String(bucketName),
 		Key:           aws.String(fileName),
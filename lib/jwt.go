@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,23 +12,50 @@ import (
 )
 
 var (
-	mu         sync.Mutex
-	muStorage  = make(map[string]any)
-	privateKey = []byte(os.Getenv("SECRET_KEY"))
+	mu        sync.Mutex
+	muStorage = make(map[string]any)
 )
 
 func getToken(bearerToken string) (*jwt.Token, error) {
-	strToken := TokenFromRequest(bearerToken)
-	token, err := jwt.Parse(strToken, func(token *jwt.Token) (any, error) {
+	token, err := parseJwt(bearerToken, Env.Bytes("SECRET_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token: %v", err)
+	}
+	return token, nil
+}
+
+func parseJwt(bearerToken string, secret []byte) (*jwt.Token, error) {
+	return jwt.Parse(TokenFromRequest(bearerToken), func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return privateKey, nil
+		return secret, nil
 	})
+}
+
+// VerifyJwt checks bearerToken ("Bearer <jwt>") against SECRET_KEY and
+// returns its "id" claim. Unlike LoggedUser it does not record the user in
+// the shared storage, so concurrent requests cannot see each other's id.
+// It fails with ErrTokenSecret when SECRET_KEY is empty and ErrJwtInvalid
+// for a bad token or one without an id.
+func VerifyJwt(bearerToken string) (uint32, error) {
+	secret := Env.Bytes("SECRET_KEY")
+	if len(secret) == 0 {
+		return 0, ErrTokenSecret
+	}
+	token, err := parseJwt(bearerToken, secret)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing token: %v", err)
+		return 0, fmt.Errorf("%w: %w", ErrJwtInvalid, err)
 	}
-	return token, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, ErrJwtInvalid
+	}
+	id, ok := claims["id"]
+	if !ok {
+		return 0, ErrJwtInvalid
+	}
+	return ToUint32(id), nil
 }
 
 func ValidateJWT(str string) error {
@@ -136,18 +162,40 @@ type jwtClaim struct {
 	jwt.StandardClaims
 }
 
-func JwtGenerate(userId uint32) string {
+var (
+	ErrJwtSign    = errors.New("cannot sign token")
+	ErrJwtInvalid = errors.New("invalid token")
+	ErrHostname   = errors.New("cannot determine host name")
+)
+
+// SignJwt issues the five-year token JwtGenerate has always issued, signed
+// with SECRET_KEY.
+func SignJwt(userId uint32) (string, error) {
+	secret := Env.Bytes("SECRET_KEY")
+	if len(secret) == 0 {
+		return "", ErrTokenSecret
+	}
 	claims := &jwtClaim{
 		userId,
 		jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Hour * 24 * 365 * 5).Unix(),
-			Issuer:    os.Getenv("SITE_NAME"),
+			Issuer:    Env.Get("SITE_NAME"),
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	// encoded the web token
-	t, err := token.SignedString([]byte(os.Getenv("SECRET_KEY")))
+	t, err := token.SignedString(secret)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrJwtSign, err)
+	}
+	return t, nil
+}
+
+// Deprecated: use SignJwt. JwtGenerate panics when the token cannot be
+// signed.
+func JwtGenerate(userId uint32) string {
+	t, err := SignJwt(userId)
 	if err != nil {
 		panic(err)
 	}
@@ -155,12 +203,12 @@ func JwtGenerate(userId uint32) string {
 }
 
 func GenerateJWT(userId uint32) (string, error) {
-	privateKey := []byte(os.Getenv("SECRET_KEY"))
+	privateKey := Env.Bytes("SECRET_KEY")
 	if len(privateKey) == 0 {
 		return "", errors.New("private key is empty")
 	}
-	tokenTTL, _ := strconv.Atoi(os.Getenv("TOKEN_TTL"))
-	sapi := os.Getenv("API_ENDPOINT")
+	tokenTTL := Env.Int("TOKEN_TTL", 0)
+	sapi := Env.Get("API_ENDPOINT")
 	claimsParams := jwt.MapClaims{
 		"id":  userId,
 		"iss": sapi,
@@ -172,8 +220,18 @@ func GenerateJWT(userId uint32) (string, error) {
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claimsParams).SignedString(privateKey)
 }
 
-func FindHostName() string {
+func Hostname() (string, error) {
 	name, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrHostname, err)
+	}
+	return name, nil
+}
+
+// Deprecated: use Hostname. FindHostName panics when the kernel does not
+// report a host name.
+func FindHostName() string {
+	name, err := Hostname()
 	if err != nil {
 		panic(err)
 	}
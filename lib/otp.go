@@ -0,0 +1,316 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AlphabetDigits       = "0123456789"
+	AlphabetAlphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+type OtpAlgorithm string
+
+const (
+	OtpSHA1   OtpAlgorithm = "SHA1"
+	OtpSHA256 OtpAlgorithm = "SHA256"
+	OtpSHA512 OtpAlgorithm = "SHA512"
+)
+
+var (
+	ErrOtpInvalid  = errors.New("otp: invalid code")
+	ErrOtpReplayed = errors.New("otp: code already used")
+	ErrOtpSecret   = errors.New("otp: invalid secret")
+)
+
+var otpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RandomBytes returns n bytes read from crypto/rand.
+func RandomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// RandomInt returns a uniform random number in [low, hi).
+func RandomInt(low int, hi int) (int, error) {
+	if hi <= low {
+		return 0, fmt.Errorf("invalid range [%d, %d)", low, hi)
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(hi-low)))
+	if err != nil {
+		return 0, err
+	}
+	return low + int(n.Int64()), nil
+}
+
+// RandomString returns a string of length n drawn uniformly from alphabet.
+func RandomString(n int, alphabet string) (string, error) {
+	if alphabet == "" {
+		return "", errors.New("empty alphabet")
+	}
+	max := big.NewInt(int64(len(alphabet)))
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+// RandomID returns a 128-bit random identifier encoded as hex.
+func RandomID() (string, error) {
+	b, err := RandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RandomUUID returns a random (version 4) UUID.
+func RandomUUID() (string, error) {
+	b, err := RandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// NumericCode returns a zero-padded numeric one-time code of the given length.
+func NumericCode(length int) (string, error) {
+	if length <= 0 {
+		return "", errors.New("code length must be positive")
+	}
+	return RandomString(length, AlphabetDigits)
+}
+
+// GenerateOtpSecret returns a base32 (unpadded) secret of size random bytes,
+// suitable for authenticator apps. 20 bytes is the RFC 4226 recommendation.
+func GenerateOtpSecret(size int) (string, error) {
+	if size <= 0 {
+		size = 20
+	}
+	b, err := RandomBytes(size)
+	if err != nil {
+		return "", err
+	}
+	return otpEncoding.EncodeToString(b), nil
+}
+
+func decodeOtpSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := otpEncoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrOtpSecret
+	}
+	return key, nil
+}
+
+func (a OtpAlgorithm) hash() func() hash.Hash {
+	switch a {
+	case OtpSHA256:
+		return sha256.New
+	case OtpSHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// maxOtpDigits is the longest code the 31-bit truncated value can fill.
+const maxOtpDigits = 10
+
+// OtpOptions configures HOTP/TOTP generation and verification.
+// Zero values fall back to the RFC defaults (SHA1, 6 digits, 30 seconds);
+// Digits is capped at 10 and Period is at least one second.
+type OtpOptions struct {
+	Algorithm OtpAlgorithm
+	Digits    int
+	Period    time.Duration
+	// Skew is the number of steps accepted either side of the expected one.
+	Skew int
+}
+
+func (o OtpOptions) withDefaults() OtpOptions {
+	if o.Algorithm == "" {
+		o.Algorithm = OtpSHA1
+	}
+	if o.Digits <= 0 {
+		o.Digits = 6
+	}
+	if o.Digits > maxOtpDigits {
+		o.Digits = maxOtpDigits
+	}
+	if o.Period <= 0 {
+		o.Period = 30 * time.Second
+	}
+	if o.Period < time.Second {
+		o.Period = time.Second
+	}
+	if o.Skew < 0 {
+		o.Skew = 0
+	}
+	return o
+}
+
+func hotp(key []byte, counter uint64, opts OtpOptions) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(opts.Algorithm.hash(), key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint64(1)
+	for i := 0; i < opts.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", opts.Digits, uint64(code)%mod)
+}
+
+// GenerateHOTP returns the RFC 4226 code for secret at counter.
+func GenerateHOTP(secret string, counter uint64, opts OtpOptions) (string, error) {
+	key, err := decodeOtpSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, counter, opts.withDefaults()), nil
+}
+
+// GenerateTOTP returns the RFC 6238 code for secret at time t.
+func GenerateTOTP(secret string, t time.Time, opts OtpOptions) (string, error) {
+	opts = opts.withDefaults()
+	return GenerateHOTP(secret, totpStep(t, opts.Period), opts)
+}
+
+func totpStep(t time.Time, period time.Duration) uint64 {
+	return uint64(t.Unix()) / uint64(period/time.Second)
+}
+
+// ValidateHOTP checks code against the counters [counter, counter+Skew] and
+// returns the matched counter; the caller should persist matched+1.
+func ValidateHOTP(secret string, code string, counter uint64, opts OtpOptions) (uint64, error) {
+	opts = opts.withDefaults()
+	key, err := decodeOtpSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i <= opts.Skew; i++ {
+		if otpEqual(hotp(key, counter+uint64(i), opts), code) {
+			return counter + uint64(i), nil
+		}
+	}
+	return 0, ErrOtpInvalid
+}
+
+// ValidateTOTP checks code against the steps around t within the Skew window
+// and returns the matched time step.
+func ValidateTOTP(secret string, code string, t time.Time, opts OtpOptions) (uint64, error) {
+	opts = opts.withDefaults()
+	key, err := decodeOtpSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	step := totpStep(t, opts.Period)
+	for i := -opts.Skew; i <= opts.Skew; i++ {
+		if i < 0 && uint64(-i) > step {
+			continue
+		}
+		s := uint64(int64(step) + int64(i))
+		if otpEqual(hotp(key, s, opts), code) {
+			return s, nil
+		}
+	}
+	return 0, ErrOtpInvalid
+}
+
+func otpEqual(expected, code string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.TrimSpace(code))) == 1
+}
+
+// OtpVerifier validates TOTP codes and rejects any code whose time step is not
+// newer than the last one accepted for the same subject. The zero value is
+// ready to use with the default options.
+type OtpVerifier struct {
+	mu      sync.Mutex
+	Options OtpOptions
+	used    map[string]uint64
+}
+
+func NewOtpVerifier(opts OtpOptions) *OtpVerifier {
+	return &OtpVerifier{Options: opts, used: make(map[string]uint64)}
+}
+
+// Verify validates code for subject (e.g. a user id) and records its step.
+func (v *OtpVerifier) Verify(subject string, secret string, code string) error {
+	step, err := ValidateTOTP(secret, code, time.Now(), v.Options)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.used == nil {
+		v.used = make(map[string]uint64)
+	}
+	if last, ok := v.used[subject]; ok && step <= last {
+		return ErrOtpReplayed
+	}
+	v.used[subject] = step
+	return nil
+}
+
+// Forget drops the replay state for subject.
+func (v *OtpVerifier) Forget(subject string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.used, subject)
+}
+
+// OtpProvisioningURI builds the otpauth:// URI understood by authenticator
+// apps. kind is "totp" or "hotp"; counter is only used for hotp.
+func OtpProvisioningURI(kind string, secret string, issuer string, account string, counter uint64, opts OtpOptions) string {
+	opts = opts.withDefaults()
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", string(opts.Algorithm))
+	params.Set("digits", strconv.Itoa(opts.Digits))
+	if kind == "hotp" {
+		params.Set("counter", strconv.FormatUint(counter, 10))
+	} else {
+		kind = "totp"
+		params.Set("period", strconv.Itoa(int(opts.Period/time.Second)))
+	}
+	return fmt.Sprintf("otpauth://%s/%s?%s", kind, label, params.Encode())
+}
@@ -0,0 +1,142 @@
+package lib
+
+import (
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+)
+
+func otpTestSecret(seed string) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(seed))
+}
+
+const (
+	otpSeedSHA1   = "12345678901234567890"
+	otpSeedSHA256 = "12345678901234567890123456789012"
+	otpSeedSHA512 = "1234567890123456789012345678901234567890123456789012345678901234"
+)
+
+// RFC 4226 appendix D.
+func TestGenerateHOTP(t *testing.T) {
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	secret := otpTestSecret(otpSeedSHA1)
+	for counter, code := range want {
+		got, err := GenerateHOTP(secret, uint64(counter), OtpOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("counter %d: got %s, want %s", counter, got, code)
+		}
+	}
+}
+
+// RFC 6238 appendix B.
+func TestGenerateTOTP(t *testing.T) {
+	tests := []struct {
+		unix   int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			algorithm OtpAlgorithm
+			seed      string
+			want      string
+		}{
+			{OtpSHA1, otpSeedSHA1, tt.sha1},
+			{OtpSHA256, otpSeedSHA256, tt.sha256},
+			{OtpSHA512, otpSeedSHA512, tt.sha512},
+		} {
+			opts := OtpOptions{Algorithm: c.algorithm, Digits: 8}
+			got, err := GenerateTOTP(otpTestSecret(c.seed), time.Unix(tt.unix, 0), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("%s at %d: got %s, want %s", c.algorithm, tt.unix, got, c.want)
+			}
+		}
+	}
+}
+
+func TestOtpDigitsCapped(t *testing.T) {
+	code, err := GenerateHOTP(otpTestSecret(otpSeedSHA1), 0, OtpOptions{Digits: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1284755224 is the RFC 4226 truncated value for counter 0.
+	if code != "1284755224" {
+		t.Errorf("got %s", code)
+	}
+}
+
+func TestValidateHOTPWindow(t *testing.T) {
+	secret := otpTestSecret(otpSeedSHA1)
+	matched, err := ValidateHOTP(secret, "969429", 1, OtpOptions{Skew: 2})
+	if err != nil || matched != 3 {
+		t.Errorf("got %d, %v; want 3", matched, err)
+	}
+	if _, err := ValidateHOTP(secret, "969429", 1, OtpOptions{Skew: 1}); !errors.Is(err, ErrOtpInvalid) {
+		t.Errorf("outside window: got %v", err)
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret := otpTestSecret(otpSeedSHA1)
+	now := time.Unix(1111111111, 0)
+	previous, _ := GenerateTOTP(secret, now.Add(-30*time.Second), OtpOptions{})
+
+	if _, err := ValidateTOTP(secret, previous, now, OtpOptions{}); !errors.Is(err, ErrOtpInvalid) {
+		t.Errorf("skew 0: got %v", err)
+	}
+	step, err := ValidateTOTP(secret, previous, now, OtpOptions{Skew: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(1111111111/30 - 1); step != want {
+		t.Errorf("step: got %d, want %d", step, want)
+	}
+	if _, err := ValidateTOTP("not base32!", previous, now, OtpOptions{}); !errors.Is(err, ErrOtpSecret) {
+		t.Errorf("bad secret: got %v", err)
+	}
+}
+
+func TestOtpVerifierReplay(t *testing.T) {
+	secret, err := GenerateOtpSecret(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := GenerateTOTP(secret, time.Now(), OtpOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v OtpVerifier
+	v.Options.Skew = 1
+	if err := v.Verify("alice", secret, code); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := v.Verify("alice", secret, code); !errors.Is(err, ErrOtpReplayed) {
+		t.Errorf("replay: got %v", err)
+	}
+	if err := v.Verify("bob", secret, code); err != nil {
+		t.Errorf("other subject: %v", err)
+	}
+	v.Forget("alice")
+	if err := v.Verify("alice", secret, code); err != nil {
+		t.Errorf("after Forget: %v", err)
+	}
+	if err := v.Verify("alice", secret, "000000x"); !errors.Is(err, ErrOtpInvalid) {
+		t.Errorf("wrong code: got %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package lib
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPasswordMismatch = bcrypt.ErrMismatchedHashAndPassword
+	ErrUnknownHash      = errors.New("unknown password hash format")
+	ErrMalformedHash    = errors.New("malformed password hash")
+)
+
+// PasswordHasher hashes and verifies passwords in a single algorithm.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) error
+	// Supports reports whether hash was produced by this algorithm.
+	Supports(hash string) bool
+	// NeedsRehash reports whether hash was produced with weaker parameters
+	// than the hasher is currently configured with.
+	NeedsRehash(hash string) bool
+}
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(hash string, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+func (h *BcryptHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.Cost
+}
+
+// Argon2idHasher produces PHC formatted hashes:
+// $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher returns a hasher using the OWASP recommended minimum
+// parameters (19 MiB, 2 iterations, 1 lane).
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Limits for parameters read from stored hashes; argon2 panics below the
+// minimums and a crafted hash could otherwise ask for any amount of memory.
+const (
+	argon2MaxMemory     = 4 * 1024 * 1024 // KiB
+	argon2MaxIterations = 64
+)
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt, err := RandomBytes(int(h.SaltLength))
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(hash string, password string) error {
+	p, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	if subtle.ConstantTimeCompare(key, p.key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h *Argon2idHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	p, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return p.memory < h.Memory || p.iterations < h.Iterations || p.parallelism < h.Parallelism ||
+		uint32(len(p.salt)) < h.SaltLength || uint32(len(p.key)) < h.KeyLength
+}
+
+func parseArgon2id(hash string) (*argon2Params, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrMalformedHash
+	}
+	p := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return nil, ErrMalformedHash
+	}
+	if p.parallelism == 0 || p.iterations == 0 || p.iterations > argon2MaxIterations ||
+		p.memory < 8*uint32(p.parallelism) || p.memory > argon2MaxMemory {
+		return nil, ErrMalformedHash
+	}
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrMalformedHash
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+		return nil, ErrMalformedHash
+	}
+	return p, nil
+}
+
+// PasswordManager hashes new passwords with Hasher and verifies existing
+// hashes with whichever of Hasher or Legacy produced them.
+type PasswordManager struct {
+	Hasher PasswordHasher
+	Legacy []PasswordHasher
+}
+
+func NewPasswordManager(hasher PasswordHasher, legacy ...PasswordHasher) *PasswordManager {
+	return &PasswordManager{Hasher: hasher, Legacy: legacy}
+}
+
+// Passwords is the manager behind GenerateHashedPassword and VerifyPassword.
+// It defaults to bcrypt so hashes stay readable by other consumers; switch to
+// argon2id at startup with:
+//
+//	lib.Passwords = lib.NewPasswordManager(lib.NewArgon2idHasher(), lib.NewBcryptHasher(bcrypt.DefaultCost))
+var Passwords = NewPasswordManager(NewBcryptHasher(bcrypt.DefaultCost), NewArgon2idHasher())
+
+func (m *PasswordManager) Hash(password string) (string, error) {
+	return m.Hasher.Hash(password)
+}
+
+func (m *PasswordManager) hasherFor(hash string) PasswordHasher {
+	if m.Hasher.Supports(hash) {
+		return m.Hasher
+	}
+	for _, h := range m.Legacy {
+		if h.Supports(hash) {
+			return h
+		}
+	}
+	return nil
+}
+
+func (m *PasswordManager) Verify(hash string, password string) error {
+	h := m.hasherFor(hash)
+	if h == nil {
+		return ErrUnknownHash
+	}
+	return h.Verify(hash, password)
+}
+
+// NeedsRehash reports whether hash should be replaced, either because it uses
+// a legacy algorithm or weaker parameters than the current Hasher.
+func (m *PasswordManager) NeedsRehash(hash string) bool {
+	if !m.Hasher.Supports(hash) {
+		return true
+	}
+	return m.Hasher.NeedsRehash(hash)
+}
+
+// VerifyAndRehash verifies password and, when the stored hash is outdated,
+// returns a replacement hash for the caller to persist. newHash is empty when
+// no upgrade is needed.
+func (m *PasswordManager) VerifyAndRehash(hash string, password string) (newHash string, err error) {
+	if err = m.Verify(hash, password); err != nil {
+		return "", err
+	}
+	if !m.NeedsRehash(hash) {
+		return "", nil
+	}
+	return m.Hash(password)
+}
+
+// PasswordViolation is a single failed policy rule.
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password failed.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation `json:"violations"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// bcryptMaxBytes is the longest password bcrypt accepts.
+const bcryptMaxBytes = 72
+
+type PasswordPolicy struct {
+	// MinLength and MaxLength count characters.
+	MinLength int
+	MaxLength int
+	// MaxBytes limits the encoded length, which is what bcrypt's limit is
+	// measured in.
+	MaxBytes       int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSymbol  bool
+	RejectCommon   bool
+	CommonPassword map[string]struct{}
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    8,
+	MaxLength:    72,
+	MaxBytes:     bcryptMaxBytes,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	RejectCommon: true,
+}
+
+// Validate returns a *PasswordPolicyError listing every violated rule, or nil.
+func (p PasswordPolicy) Validate(password string) error {
+	var violations []PasswordViolation
+	add := func(code string, message string, params map[string]any) {
+		violations = append(violations, PasswordViolation{Code: code, Message: Translate(message, params)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		add("too_short", "Password must be at least {min} characters.", map[string]any{"min": p.MinLength})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add("too_long", "Password must be at most {max} characters.", map[string]any{"max": p.MaxLength})
+	} else if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		add("too_long", "Password is too long.", nil)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		add("missing_upper", "Password must contain an uppercase letter.", nil)
+	}
+	if p.RequireLower && !lower {
+		add("missing_lower", "Password must contain a lowercase letter.", nil)
+	}
+	if p.RequireDigit && !digit {
+		add("missing_digit", "Password must contain a digit.", nil)
+	}
+	if p.RequireSymbol && !symbol {
+		add("missing_symbol", "Password must contain a symbol.", nil)
+	}
+	if p.RejectCommon && p.isCommon(password) {
+		add("too_common", "Password is too common.", nil)
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (p PasswordPolicy) isCommon(password string) bool {
+	list := p.CommonPassword
+	if list == nil {
+		list = commonPasswords
+	}
+	_, ok := list[strings.ToLower(password)]
+	return ok
+}
+
+func ValidatePassword(password string) error {
+	return DefaultPasswordPolicy.Validate(password)
+}
+
+var commonPasswords = toSet([]string{
+	"123456", "123456789", "12345678", "1234567890", "12345", "1234567", "111111",
+	"123123", "000000", "654321", "666666", "121212", "112233", "987654321",
+	"password", "password1", "password12", "password123", "passw0rd", "p@ssw0rd",
+	"qwerty", "qwerty123", "qwertyuiop", "1q2w3e4r", "1qaz2wsx", "zaq12wsx",
+	"abc123", "abcd1234", "admin", "admin123", "administrator", "root", "toor",
+	"letmein", "welcome", "welcome1", "welcome123", "iloveyou", "monkey", "dragon",
+	"master", "sunshine", "princess", "football", "baseball", "superman", "batman",
+	"trustno1", "shadow", "michael", "login", "starwars", "whatever", "freedom",
+	"changeme", "secret", "test123", "default", "guest", "hello123", "aa123456",
+})
+
+func toSet(items []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
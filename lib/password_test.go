@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestPasswordHashers(t *testing.T) {
+	for name, h := range map[string]PasswordHasher{
+		"bcrypt":   NewBcryptHasher(bcrypt.MinCost),
+		"argon2id": testArgon2idHasher(),
+	} {
+		hash, err := h.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !h.Supports(hash) {
+			t.Errorf("%s: does not support its own hash %q", name, hash)
+		}
+		if err := h.Verify(hash, "correct horse"); err != nil {
+			t.Errorf("%s: verify: %v", name, err)
+		}
+		if err := h.Verify(hash, "wrong horse"); !errors.Is(err, ErrPasswordMismatch) {
+			t.Errorf("%s: wrong password: got %v", name, err)
+		}
+	}
+}
+
+func TestArgon2idMalformedHash(t *testing.T) {
+	h := testArgon2idHasher()
+	hash, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+	tests := map[string]string{
+		"no parallelism": "m=64,t=1,p=0",
+		"no iterations":  "m=64,t=0,p=1",
+		"low memory":     "m=4,t=1,p=1",
+		"huge memory":    "m=4294967295,t=1,p=1",
+		"many passes":    "m=64,t=100000,p=1",
+		"garbage":        "m=x",
+	}
+	for name, params := range tests {
+		parts[3] = params
+		bad := strings.Join(parts, "$")
+		if err := h.Verify(bad, "secret"); !errors.Is(err, ErrMalformedHash) {
+			t.Errorf("%s: got %v", name, err)
+		}
+		if !h.NeedsRehash(bad) {
+			t.Errorf("%s: NeedsRehash is false", name)
+		}
+	}
+}
+
+func TestPasswordManagerRehash(t *testing.T) {
+	legacy := NewBcryptHasher(bcrypt.MinCost)
+	oldHash, err := legacy.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewPasswordManager(testArgon2idHasher(), legacy)
+
+	if !m.NeedsRehash(oldHash) {
+		t.Error("legacy hash should need a rehash")
+	}
+	newHash, err := m.VerifyAndRehash(oldHash, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(newHash, "$argon2id$") {
+		t.Fatalf("rehash: got %q", newHash)
+	}
+	if again, err := m.VerifyAndRehash(newHash, "secret"); err != nil || again != "" {
+		t.Errorf("current hash: got %q, %v", again, err)
+	}
+	if _, err := m.VerifyAndRehash(oldHash, "wrong"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("wrong password: got %v", err)
+	}
+	if err := m.Verify("$md5$abc", "secret"); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("unknown hash: got %v", err)
+	}
+
+	stronger := testArgon2idHasher()
+	stronger.Iterations = 2
+	if !NewPasswordManager(stronger).NeedsRehash(newHash) {
+		t.Error("weaker argon2id parameters should need a rehash")
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		password string
+		codes    []string
+	}{
+		{"Abcdefg1", nil},
+		{"Ab1", []string{"too_short"}},
+		{"abcdefgh", []string{"missing_upper", "missing_digit"}},
+		{"Password1", []string{"too_common"}},
+		// 30 characters but 90 bytes: within MaxLength, beyond bcrypt's limit.
+		{"Aa1" + strings.Repeat("€", 27), []string{"too_long"}},
+	}
+	for _, tt := range tests {
+		err := ValidatePassword(tt.password)
+		var got []string
+		var policyErr *PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			for _, v := range policyErr.Violations {
+				got = append(got, v.Code)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.codes, ",") {
+			t.Errorf("%q: got %v, want %v", tt.password, got, tt.codes)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryValue formats a scalar the way MapToURLParams always has.
+func QueryValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return fmt.Sprintf("%f", v)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// NestedURLValues flattens nested maps and slices into bracket notation:
+//
+//	{"filter": {"created_at": {"gte": "2024-01-01"}}, "ids": []int{1, 2}}
+//	filter[created_at][gte]=2024-01-01&ids[]=1&ids[]=2
+func NestedURLValues(data map[string]any) url.Values {
+	params := url.Values{}
+	for key, value := range data {
+		AddQueryValues(params, key, value)
+	}
+	return params
+}
+
+// AddQueryValues adds value under key to params, expanding maps to key[sub]
+// and slices to key[] (or key[i][sub] for slices of maps).
+func AddQueryValues(params url.Values, key string, value any) {
+	if value == nil {
+		params.Add(key, "")
+		return
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			params.Add(key, QueryValue(value))
+			return
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			AddQueryValues(params, key+"["+iter.Key().String()+"]", iter.Value().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			params.Add(key, QueryValue(value))
+			return
+		}
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i).Interface()
+			if isNestedValue(item) {
+				AddQueryValues(params, key+"["+strconv.Itoa(i)+"]", item)
+			} else {
+				AddQueryValues(params, key+"[]", item)
+			}
+		}
+	default:
+		params.Add(key, QueryValue(value))
+	}
+}
+
+func isNestedValue(value any) bool {
+	if value == nil {
+		return false
+	}
+	return reflect.ValueOf(value).Kind() == reflect.Map
+}
+
+// SplitQueryKey splits "a[b][c]" into ["a", "b", "c"]; "a[]" yields ["a", ""].
+func SplitQueryKey(key string) []string {
+	idx := strings.Index(key, "[")
+	if idx <= 0 || !strings.HasSuffix(key, "]") {
+		return []string{key}
+	}
+	parts := []string{key[:idx]}
+	rest := key[idx:]
+	for len(rest) > 0 {
+		if rest[0] != '[' {
+			return []string{key}
+		}
+		end := strings.Index(rest, "]")
+		if end == -1 {
+			return []string{key}
+		}
+		parts = append(parts, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return parts
+}
+
+// ParseNestedQuery is the inverse of NestedURLValues. Repeated keys and key[]
+// become []any, key[sub] becomes map[string]any and everything else a string.
+func ParseNestedQuery(values url.Values) map[string]any {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]any)
+	for _, key := range keys {
+		path := SplitQueryKey(key)
+		for _, value := range values[key] {
+			setNestedValue(result, path, value, len(values[key]) > 1)
+		}
+	}
+	for key, value := range result {
+		result[key] = indexedMapsToSlices(value)
+	}
+	return result
+}
+
+func setNestedValue(node map[string]any, path []string, value string, repeated bool) {
+	key := path[0]
+	if len(path) == 1 {
+		if existing, ok := node[key]; ok && repeated {
+			if list, isList := existing.([]any); isList {
+				node[key] = append(list, value)
+				return
+			}
+			node[key] = []any{existing, value}
+			return
+		}
+		if repeated {
+			node[key] = []any{value}
+			return
+		}
+		node[key] = value
+		return
+	}
+	if len(path) == 2 && path[1] == "" {
+		list, _ := node[key].([]any)
+		node[key] = append(list, value)
+		return
+	}
+	child, ok := node[key].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		node[key] = child
+	}
+	setNestedValue(child, path[1:], value, repeated)
+}
+
+// indexedMapsToSlices turns maps keyed "0".."n-1" (from key[0][sub]) back
+// into slices.
+func indexedMapsToSlices(value any) any {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	for k, v := range m {
+		m[k] = indexedMapsToSlices(v)
+	}
+	if len(m) == 0 {
+		return m
+	}
+	list := make([]any, len(m))
+	for k, v := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		list[i] = v
+	}
+	return list
+}
@@ -6,17 +6,15 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	mrand "math/rand"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/TechAlkurn/core/logger"
 	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -26,41 +24,59 @@ SecurityEncryptionBytes := lib.SecurityEncryptionKey()
 */
 var SecurityEncryptionBytes = []byte{35, 213, 66, 145, 241, 141, 199, 3, 87, 98, 128, 181, 22, 153, 174, 99, 53, 27, 214, 30, 69, 85, 36, 3, 211, 91, 136, 101, 201, 187, 81, 26}
 
-var letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-
+// GenerateRandomKey returns a random alphanumeric key of the given length.
+// crypto/rand only fails when the OS entropy source is unavailable, which the
+// standard library itself treats as fatal.
 func GenerateRandomKey(length int32) string {
-	b := make([]byte, length)
-	// rand.Read(b)
-	for i := range b {
-		b[i] = letterBytes[mrand.Int63()%int64(len(letterBytes))]
+	key, err := RandomString(int(length), AlphabetAlphanumeric)
+	if err != nil {
+		panic(err)
 	}
-	// return string(b)
-	encodedKey := Encode(b)
-	return string(encodedKey[0:length])
+	return key
 }
 
+// GenerateOtp returns a random number in [low, hi). Prefer NumericCode for
+// fixed-length, zero-padded codes.
 func GenerateOtp(low int, hi int) int {
-	return low + mrand.Intn(hi-low)
+	otp, err := RandomInt(low, hi)
+	if err != nil {
+		panic(err)
+	}
+	return otp
 }
 
 func GenerateHashedPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		log.Fatal("Error", err)
-	}
-	return string(hashedPassword), err
+	return Passwords.Hash(password)
 }
 
 func VerifyPassword(hashedPassword string, candidatePassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(candidatePassword))
+	return Passwords.Verify(hashedPassword, candidatePassword)
+}
+
+// PasswordNeedsRehash reports whether a stored hash should be upgraded after
+// a successful login.
+func PasswordNeedsRehash(hashedPassword string) bool {
+	return Passwords.NeedsRehash(hashedPassword)
 }
 
 func Encode(b []byte) string {
 	return base64.StdEncoding.EncodeToString(b)
 }
 
-func Decode(s string) []byte {
+var ErrInvalidBase64 = errors.New("invalid base64")
+
+// DecodeBase64 reverses Encode.
+func DecodeBase64(s string) ([]byte, error) {
 	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBase64, err)
+	}
+	return data, nil
+}
+
+// Deprecated: use DecodeBase64. Decode panics on invalid input.
+func Decode(s string) []byte {
+	data, err := DecodeBase64(s)
 	if err != nil {
 		panic(err)
 	}
@@ -105,12 +121,20 @@ func ValidateToken(token string, signedJWTKey string) (any, error) {
 	return claims["sub"], nil
 }
 
+// IsPasswordResetTokenValid only checks the expiry embedded in a legacy
+// "<random>_<unix time>" token and must be paired with a stored-token lookup.
+//
+// Deprecated: issue tokens with Tokens.Issue(PurposePasswordReset, ...) and
+// check them with Tokens.Consume, which are signed and single-use.
 func IsPasswordResetTokenValid(token string) bool {
 	if IsEmpty(token) {
 		return false
 	}
 	timestamp := strings.Split(string(token), "_")
-	rawTime, err := strconv.ParseUint(timestamp[1], 10, 64)
+	if len(timestamp) < 2 {
+		return false
+	}
+	rawTime, err := strconv.ParseUint(timestamp[len(timestamp)-1], 10, 64)
 	if err != nil {
 		return false
 	}
@@ -121,10 +145,10 @@ func SecurityEncryptionKey() (key []byte) {
 	salt := make([]byte, 16) // Generate a random salt
 	_, err := rand.Read(salt)
 	if err != nil {
-		fmt.Println("Error generating salt:", err)
+		l.Error("Error generating salt", logger.Err(err))
 		return
 	}
-	key = pbkdf2.Key([]byte(os.Getenv("ENCRYPTION_KEY")), make([]byte, 16), 1000, 32, sha256.New)
+	key = pbkdf2.Key(Env.Bytes("ENCRYPTION_KEY"), make([]byte, 16), 1000, 32, sha256.New)
 	return
 }
 
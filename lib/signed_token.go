@@ -0,0 +1,192 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TokenPurpose string
+
+const (
+	PurposePasswordReset TokenPurpose = "reset"
+	PurposeVerifyEmail   TokenPurpose = "verify-email"
+	PurposeMagicLink     TokenPurpose = "magic-link"
+)
+
+var (
+	ErrTokenMalformed = errors.New("token is malformed")
+	ErrTokenSignature = errors.New("token signature is invalid")
+	ErrTokenExpired   = errors.New("token has expired")
+	ErrTokenPurpose   = errors.New("token purpose does not match")
+	ErrTokenConsumed  = errors.New("token has already been used")
+	ErrTokenSecret    = errors.New("token secret is empty")
+)
+
+// TokenStore records consumed token ids so a token can only be used once.
+type TokenStore interface {
+	// Consume marks id as used until expires and reports whether it was
+	// unused before the call.
+	Consume(id string, expires time.Time) bool
+}
+
+type memoryTokenStore struct {
+	mu    sync.Mutex
+	store map[string]time.Time
+}
+
+// NewMemoryTokenStore returns a process-local TokenStore. Use a shared store
+// (database, redis) when running more than one instance.
+func NewMemoryTokenStore() TokenStore {
+	return &memoryTokenStore{store: make(map[string]time.Time)}
+}
+
+func (s *memoryTokenStore) Consume(id string, expires time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, exp := range s.store {
+		if now.After(exp) {
+			delete(s.store, key)
+		}
+	}
+	if _, ok := s.store[id]; ok {
+		return false
+	}
+	s.store[id] = expires
+	return true
+}
+
+type tokenPayload struct {
+	Id      string       `json:"i"`
+	Purpose TokenPurpose `json:"p"`
+	Subject string       `json:"s"`
+	Expires int64        `json:"e"`
+}
+
+// SignedTokens issues and verifies HMAC-SHA256 signed, expiring, single-use
+// tokens of the form base64url(payload).base64url(signature).
+type SignedTokens struct {
+	Secret []byte
+	Store  TokenStore
+	// TTL holds the default lifetime per purpose used by Issue when ttl is 0.
+	TTL map[TokenPurpose]time.Duration
+}
+
+func NewSignedTokens(secret []byte, store TokenStore) *SignedTokens {
+	if store == nil {
+		store = NewMemoryTokenStore()
+	}
+	return &SignedTokens{
+		Secret: secret,
+		Store:  store,
+		TTL: map[TokenPurpose]time.Duration{
+			PurposePasswordReset: 2 * time.Hour,
+			PurposeVerifyEmail:   48 * time.Hour,
+			PurposeMagicLink:     15 * time.Minute,
+		},
+	}
+}
+
+// Tokens signs with Env SECRET_KEY, read on every call so reloads apply.
+var Tokens = NewSignedTokens(nil, nil)
+
+func (t *SignedTokens) key() []byte {
+	if len(t.Secret) > 0 {
+		return t.Secret
+	}
+	return Env.Bytes("SECRET_KEY")
+}
+
+func (t *SignedTokens) sign(data string) []byte {
+	mac := hmac.New(sha256.New, t.key())
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Issue returns a token binding subject to purpose. A zero ttl uses the
+// purpose default from TTL, falling back to one hour.
+func (t *SignedTokens) Issue(purpose TokenPurpose, subject string, ttl time.Duration) (string, error) {
+	if len(t.key()) == 0 {
+		return "", ErrTokenSecret
+	}
+	if ttl <= 0 {
+		if ttl = t.TTL[purpose]; ttl <= 0 {
+			ttl = time.Hour
+		}
+	}
+	id, err := RandomID()
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(tokenPayload{
+		Id:      id,
+		Purpose: purpose,
+		Subject: subject,
+		Expires: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	data := base64.RawURLEncoding.EncodeToString(payload)
+	return data + "." + base64.RawURLEncoding.EncodeToString(t.sign(data)), nil
+}
+
+func (t *SignedTokens) parse(token string, purpose TokenPurpose) (*tokenPayload, error) {
+	if len(t.key()) == 0 {
+		return nil, ErrTokenSecret
+	}
+	data, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrTokenMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if !hmac.Equal(signature, t.sign(data)) {
+		return nil, ErrTokenSignature
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var payload tokenPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if payload.Purpose != purpose {
+		return nil, ErrTokenPurpose
+	}
+	if time.Now().Unix() > payload.Expires {
+		return nil, ErrTokenExpired
+	}
+	return &payload, nil
+}
+
+// Verify checks the token without consuming it and returns its subject.
+func (t *SignedTokens) Verify(token string, purpose TokenPurpose) (string, error) {
+	payload, err := t.parse(token, purpose)
+	if err != nil {
+		return "", err
+	}
+	return payload.Subject, nil
+}
+
+// Consume verifies the token, marks it used and returns its subject. A second
+// call with the same token returns ErrTokenConsumed.
+func (t *SignedTokens) Consume(token string, purpose TokenPurpose) (string, error) {
+	payload, err := t.parse(token, purpose)
+	if err != nil {
+		return "", err
+	}
+	if !t.Store.Consume(payload.Id, time.Unix(payload.Expires, 0)) {
+		return "", ErrTokenConsumed
+	}
+	return payload.Subject, nil
+}
@@ -0,0 +1,102 @@
+package lib
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedTokensConsume(t *testing.T) {
+	tokens := NewSignedTokens([]byte("test-secret"), nil)
+	token, err := tokens.Issue(PurposePasswordReset, "42", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if subject, err := tokens.Verify(token, PurposePasswordReset); err != nil || subject != "42" {
+		t.Fatalf("Verify: got %q, %v", subject, err)
+	}
+	// Verify does not consume, so the token is still good once.
+	if subject, err := tokens.Consume(token, PurposePasswordReset); err != nil || subject != "42" {
+		t.Fatalf("Consume: got %q, %v", subject, err)
+	}
+	if _, err := tokens.Consume(token, PurposePasswordReset); !errors.Is(err, ErrTokenConsumed) {
+		t.Errorf("replay: got %v", err)
+	}
+}
+
+func TestSignedTokensRejected(t *testing.T) {
+	tokens := NewSignedTokens([]byte("test-secret"), nil)
+	token, err := tokens.Issue(PurposeVerifyEmail, "42", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, sig, _ := strings.Cut(token, ".")
+
+	forged, _ := json.Marshal(tokenPayload{Id: "x", Purpose: PurposeVerifyEmail, Subject: "1", Expires: time.Now().Add(time.Hour).Unix()})
+	forgedData := base64.RawURLEncoding.EncodeToString(forged)
+
+	expired, _ := json.Marshal(tokenPayload{Id: "y", Purpose: PurposeVerifyEmail, Subject: "42", Expires: time.Now().Add(-time.Second).Unix()})
+	expiredData := base64.RawURLEncoding.EncodeToString(expired)
+	expiredToken := expiredData + "." + base64.RawURLEncoding.EncodeToString(tokens.sign(expiredData))
+
+	rotated := NewSignedTokens([]byte("new-secret"), nil)
+
+	tests := []struct {
+		name    string
+		tokens  *SignedTokens
+		token   string
+		purpose TokenPurpose
+		want    error
+	}{
+		{"wrong purpose", tokens, token, PurposePasswordReset, ErrTokenPurpose},
+		{"expired", tokens, expiredToken, PurposeVerifyEmail, ErrTokenExpired},
+		{"forged payload", tokens, forgedData + "." + sig, PurposeVerifyEmail, ErrTokenSignature},
+		{"rotated secret", rotated, token, PurposeVerifyEmail, ErrTokenSignature},
+		{"no separator", tokens, data, PurposeVerifyEmail, ErrTokenMalformed},
+		{"bad signature encoding", tokens, data + ".!!", PurposeVerifyEmail, ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		if _, err := tt.tokens.Consume(tt.token, tt.purpose); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	// None of the failures above may have used up the real token.
+	if _, err := tokens.Consume(token, PurposeVerifyEmail); err != nil {
+		t.Errorf("valid token after failures: %v", err)
+	}
+}
+
+func TestSignedTokensDefaultTTL(t *testing.T) {
+	tokens := NewSignedTokens([]byte("test-secret"), nil)
+	token, err := tokens.Issue(PurposeMagicLink, "42", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := tokens.parse(token, PurposeMagicLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ttl := time.Until(time.Unix(payload.Expires, 0))
+	if ttl <= 14*time.Minute || ttl > 15*time.Minute {
+		t.Errorf("magic link ttl: got %s, want 15m", ttl)
+	}
+}
+
+func TestMemoryTokenStoreExpiry(t *testing.T) {
+	store := NewMemoryTokenStore()
+	if !store.Consume("a", time.Now().Add(-time.Second)) {
+		t.Fatal("first Consume should succeed")
+	}
+	// The expired entry is purged, so the id is accepted again; parse
+	// rejects the expired token before it gets here.
+	if !store.Consume("a", time.Now().Add(time.Hour)) {
+		t.Error("expired entry was not purged")
+	}
+	if store.Consume("a", time.Now().Add(time.Hour)) {
+		t.Error("live entry consumed twice")
+	}
+}
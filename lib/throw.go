@@ -1,7 +1,251 @@
 package lib
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
 
 func Throw(message string) error {
 	return errors.New(message)
 }
+
+// Error is an application error with a stable machine-readable code, a
+// client-facing message and the statuses to answer with over HTTP and gRPC.
+// Declare the errors a package returns once and derive from them per call:
+//
+//	var ErrUserNotFound = lib.NewError("USER_NOT_FOUND", codes.NotFound, "User {id} not found")
+//
+//	return ErrUserNotFound.With("id", id).Wrap(err)
+//
+// errors.Is matches on Code, so the derived error still is ErrUserNotFound.
+// Message may hold {placeholders}, filled from Details by Translate.
+type Error struct {
+	Code       string
+	Message    string
+	HTTPStatus int
+	GRPCCode   codes.Code
+	Details    map[string]any
+	cause      error
+	// statusDetails are the details of the status an Error was built from
+	// by FromStatus, other than its ErrorInfo, passed on by GRPCStatus.
+	statusDetails []*anypb.Any
+}
+
+// NewError returns an Error whose HTTP status follows grpcCode.
+func NewError(code string, grpcCode codes.Code, message string) *Error {
+	return &Error{
+		Code:       code,
+		Message:    message,
+		HTTPStatus: HTTPStatusFromCode(grpcCode),
+		GRPCCode:   grpcCode,
+	}
+}
+
+var (
+	ErrCanceled         = NewError("CANCELED", codes.Canceled, "request is canceled")
+	ErrDeadlineExceeded = NewError("DEADLINE_EXCEEDED", codes.DeadlineExceeded, "deadline is exceeded")
+	ErrInternal         = NewError("INTERNAL", codes.Internal, "internal error")
+)
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Text() + ": " + e.cause.Error()
+	}
+	return e.Text()
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Text is Message with its placeholders filled from Details.
+func (e *Error) Text() string {
+	return Translate(e.Message, e.Details)
+}
+
+// Localize looks the message up in messages, a catalog for one locale keyed
+// by Code, and fills in its placeholders. It falls back to Text.
+func (e *Error) Localize(messages map[string]string) string {
+	if message, ok := messages[e.Code]; ok {
+		return Translate(message, e.Details)
+	}
+	return e.Text()
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	c.Details = maps.Clone(e.Details)
+	return &c
+}
+
+// With returns a copy of e with key set in Details.
+func (e *Error) With(key string, value any) *Error {
+	c := e.clone()
+	if c.Details == nil {
+		c.Details = map[string]any{}
+	}
+	c.Details[key] = value
+	return c
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *Error) Wrap(err error) *Error {
+	c := e.clone()
+	c.cause = err
+	return c
+}
+
+// Errorf returns a copy of e with a new message.
+func (e *Error) Errorf(format string, args ...any) *Error {
+	c := e.clone()
+	c.Message = fmt.Sprintf(format, args...)
+	return c
+}
+
+// WithHTTPStatus returns a copy of e answered with code over HTTP.
+func (e *Error) WithHTTPStatus(code int) *Error {
+	c := e.clone()
+	c.HTTPStatus = code
+	return c
+}
+
+// GRPCStatus converts e to a status carrying an ErrorInfo detail with the
+// code and details, followed by the other details of the status e came
+// from, if any. gRPC servers and status.FromError pick it up directly.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.GRPCCode, e.Text())
+	info := &errdetails.ErrorInfo{Reason: e.Code}
+	if len(e.Details) > 0 {
+		info.Metadata = make(map[string]string, len(e.Details))
+		for key, value := range e.Details {
+			info.Metadata[key] = ToString(value)
+		}
+	}
+	withDetails, err := st.WithDetails(info)
+	if err != nil {
+		return st
+	}
+	if len(e.statusDetails) == 0 {
+		return withDetails
+	}
+	p := withDetails.Proto()
+	p.Details = append(p.Details, e.statusDetails...)
+	return status.FromProto(p)
+}
+
+// FromStatus is the reverse of GRPCStatus. Statuses from other services get
+// a code derived from their gRPC code. Details other than ErrorInfo, such
+// as BadRequest or RetryInfo, are kept for GRPCStatus.
+func FromStatus(st *status.Status) *Error {
+	e := NewError(ErrorCode(st.Code()), st.Code(), st.Message())
+	for _, detail := range st.Proto().GetDetails() {
+		if !detail.MessageIs((*errdetails.ErrorInfo)(nil)) {
+			e.statusDetails = append(e.statusDetails, detail)
+		}
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+		if info.GetReason() != "" {
+			e.Code = info.GetReason()
+		}
+		if len(info.GetMetadata()) > 0 {
+			e.Details = make(map[string]any, len(info.GetMetadata()))
+			for key, value := range info.GetMetadata() {
+				e.Details[key] = value
+			}
+		}
+	}
+	e.cause = st.Err()
+	return e
+}
+
+// AsError returns err as an *Error: the one it wraps, one rebuilt from its
+// gRPC status, or ErrInternal wrapping it. nil stays nil.
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+		return FromStatus(st)
+	}
+	return ErrInternal.Wrap(err)
+}
+
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatusFromCode maps a gRPC code to the HTTP status sent to clients.
+// Canceled maps to the non-standard 499 Client Closed Request.
+func HTTPStatusFromCode(code codes.Code) int {
+	if s, ok := grpcHTTPStatus[code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+var grpcErrorCodes = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+// ErrorCode formats a gRPC code as a machine-readable code, e.g.
+// codes.InvalidArgument gives "INVALID_ARGUMENT". Codes outside the gRPC
+// spec give "UNKNOWN".
+func ErrorCode(code codes.Code) string {
+	if name, ok := grpcErrorCodes[code]; ok {
+		return name
+	}
+	return grpcErrorCodes[codes.Unknown]
+}
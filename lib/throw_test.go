@@ -0,0 +1,91 @@
+package lib
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestErrorCode(t *testing.T) {
+	for code, want := range map[codes.Code]string{
+		codes.OK:                 "OK",
+		codes.Canceled:           "CANCELED",
+		codes.InvalidArgument:    "INVALID_ARGUMENT",
+		codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+		codes.FailedPrecondition: "FAILED_PRECONDITION",
+		codes.DataLoss:           "DATA_LOSS",
+		codes.Unauthenticated:    "UNAUTHENTICATED",
+		codes.Code(99):           "UNKNOWN",
+	} {
+		if got := ErrorCode(code); got != want {
+			t.Errorf("ErrorCode(%v) = %q, want %q", code, got, want)
+		}
+	}
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if _, ok := grpcErrorCodes[code]; !ok {
+			t.Errorf("no error code for %v", code)
+		}
+	}
+}
+
+func TestErrorStatusRoundTrip(t *testing.T) {
+	errNotFound := NewError("USER_NOT_FOUND", codes.NotFound, "User {id} not found")
+	err := errNotFound.With("id", 7).Wrap(errors.New("no rows"))
+
+	if !errors.Is(err, errNotFound) {
+		t.Error("derived error does not match its sentinel")
+	}
+	if got := err.Text(); got != "User 7 not found" {
+		t.Errorf("Text = %q", got)
+	}
+	if err.HTTPStatus != http.StatusNotFound {
+		t.Errorf("HTTPStatus = %d", err.HTTPStatus)
+	}
+
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.NotFound {
+		t.Fatalf("status = %v, %v", st, ok)
+	}
+	back := FromStatus(st)
+	if !errors.Is(back, errNotFound) || back.Details["id"] != "7" || back.Text() != "User 7 not found" {
+		t.Errorf("FromStatus = %+v", back)
+	}
+
+	other := FromStatus(status.New(codes.Unavailable, "down"))
+	if other.Code != "UNAVAILABLE" || other.HTTPStatus != http.StatusServiceUnavailable {
+		t.Errorf("FromStatus without ErrorInfo = %+v", other)
+	}
+}
+
+func TestFromStatusKeepsDetails(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid").WithDetails(
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "email", Description: "is required"}}},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := FromStatus(st)
+	if e.Code != "INVALID_ARGUMENT" {
+		t.Errorf("Code = %q", e.Code)
+	}
+
+	var badRequest, retry bool
+	for _, detail := range e.GRPCStatus().Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			badRequest = len(d.GetFieldViolations()) == 1 && d.GetFieldViolations()[0].GetField() == "email"
+		case *errdetails.RetryInfo:
+			retry = d.GetRetryDelay().AsDuration().Seconds() == 3
+		}
+	}
+	if !badRequest || !retry {
+		t.Errorf("details lost: BadRequest %v, RetryInfo %v", badRequest, retry)
+	}
+}
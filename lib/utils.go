@@ -7,7 +7,6 @@ import (
 	"io"
 	"net"
 	"net/http"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -96,8 +95,7 @@ func HasField(v any, name string) bool {
 func IsLocalHost() bool {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+		fatal("Failed to list interface addresses", err)
 	}
 	isLocal := false
 	for _, addr := range addrs {
@@ -141,7 +139,7 @@ func IpAdressPort(c *gin.Context) string {
 
 func SiteUrl(template_url string, params map[string]any) string {
 	// var url string
-	url := os.Getenv("domainBase")
+	url := Env.Get("domainBase")
 	if strings.Contains(template_url, "://") {
 		url = template_url
 	}
@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	payload := []byte(`{"event":"paid"}`)
+	now := time.Now()
+	header := SignWebhook(payload, now, "current")
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		header    string
+		tolerance time.Duration
+		secrets   []string
+		want      error
+	}{
+		{"valid", payload, header, time.Minute, []string{"current"}, nil},
+		{"rotation: old and new secret accepted", payload, header, time.Minute, []string{"next", "current"}, nil},
+		{"sender signs with both secrets", payload, SignWebhook(payload, now, "old", "new"), time.Minute, []string{"new"}, nil},
+		{"wrong secret", payload, header, time.Minute, []string{"other"}, ErrWebhookSignature},
+		{"tampered payload", []byte(`{"event":"refunded"}`), header, time.Minute, []string{"current"}, ErrWebhookSignature},
+		{"too old", payload, SignWebhook(payload, now.Add(-2*time.Minute), "current"), time.Minute, []string{"current"}, ErrWebhookTimestamp},
+		{"too far ahead", payload, SignWebhook(payload, now.Add(2*time.Minute), "current"), time.Minute, []string{"current"}, ErrWebhookTimestamp},
+		{"within tolerance", payload, SignWebhook(payload, now.Add(-30*time.Second), "current"), time.Minute, []string{"current"}, nil},
+		{"tolerance disabled", payload, SignWebhook(payload, now.Add(-time.Hour), "current"), 0, []string{"current"}, nil},
+		{"missing header", payload, "", time.Minute, []string{"current"}, ErrWebhookHeader},
+		{"no signature", payload, "t=123", time.Minute, []string{"current"}, ErrWebhookHeader},
+		{"bad timestamp", payload, "t=abc,v1=00", time.Minute, []string{"current"}, ErrWebhookHeader},
+	}
+	for _, tt := range tests {
+		err := VerifyWebhook(tt.payload, tt.header, tt.tolerance, tt.secrets...)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestWebhookMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := NewWebhookVerifier("current")
+	verifier.MaxBodyBytes = 64
+
+	router := gin.New()
+	router.POST("/hook", verifier.Middleware(), func(c *gin.Context) {
+		var body map[string]any
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		raw, _ := c.Get(WebhookBodyKey)
+		c.String(http.StatusOK, "%v %s", body["event"], raw)
+	})
+
+	send := func(body string, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+		req.Header.Set(WebhookSignatureHeader, header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	body := `{"event":"paid"}`
+	if w := send(body, SignWebhook([]byte(body), time.Now(), "current")); w.Code != http.StatusOK || w.Body.String() != "paid "+body {
+		t.Errorf("valid: got %d %q", w.Code, w.Body.String())
+	}
+	if w := send(body, SignWebhook([]byte(body), time.Now(), "other")); w.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: got %d", w.Code)
+	}
+	large := `{"event":"` + strings.Repeat("x", 100) + `"}`
+	if w := send(large, SignWebhook([]byte(large), time.Now(), "current")); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("too large: got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/hook", io.MultiReader(strings.NewReader(`{"ev`), iotest.ErrReader(errors.New("connection reset"))))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("read error: got %d", w.Code)
+	}
+}
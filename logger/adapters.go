@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Adapters keep the backend's own level, output and encoding. Per-package
+// levels apply on top of that when given; use Named to tag records with the
+// package name.
+
+// FromZap logs through z.
+func FromZap(z *zap.Logger, levels ...map[string]Level) Logger {
+	return withLevels(&zapLogger{z: z}, levels)
+}
+
+// FromLogrus logs through l, a *logrus.Logger or *logrus.Entry.
+func FromLogrus(l logrus.FieldLogger, levels ...map[string]Level) Logger {
+	return withLevels(&logrusLogger{l: l}, levels)
+}
+
+// FromSlog logs through l.
+func FromSlog(l *slog.Logger, levels ...map[string]Level) Logger {
+	return withLevels(&slogLogger{l: l}, levels)
+}
+
+// withLevels filters backend by the per-package levels, if any.
+func withLevels(backend Logger, levels []map[string]Level) Logger {
+	if len(levels) == 0 || levels[0] == nil {
+		return backend
+	}
+	return &filtered{Logger: backend, levels: newLevelSet(DebugLevel, levels[0])}
+}
+
+type filtered struct {
+	Logger
+	levels *levelSet
+	name   string
+}
+
+func (f *filtered) Enabled(level Level) bool {
+	return level >= f.levels.levelFor(f.name) && f.Logger.Enabled(level)
+}
+
+func (f *filtered) Log(level Level, msg string, fields ...Field) {
+	if level >= f.levels.levelFor(f.name) {
+		f.Logger.Log(level, msg, fields...)
+	}
+}
+
+func (f *filtered) Debug(msg string, fields ...Field) { f.Log(DebugLevel, msg, fields...) }
+func (f *filtered) Info(msg string, fields ...Field)  { f.Log(InfoLevel, msg, fields...) }
+func (f *filtered) Warn(msg string, fields ...Field)  { f.Log(WarnLevel, msg, fields...) }
+func (f *filtered) Error(msg string, fields ...Field) { f.Log(ErrorLevel, msg, fields...) }
+
+func (f *filtered) With(fields ...Field) Logger {
+	return &filtered{Logger: f.Logger.With(fields...), levels: f.levels, name: f.name}
+}
+
+func (f *filtered) Named(name string) Logger {
+	return &filtered{Logger: f.Logger.Named(name), levels: f.levels, name: joinName(f.name, name)}
+}
+
+func (f *filtered) SetLevel(pkg string, level Level) {
+	f.levels.set(pkg, level)
+}
+
+type zapLogger struct {
+	z *zap.Logger
+}
+
+func zapLevel(level Level) zapcore.Level {
+	switch level {
+	case DebugLevel:
+		return zapcore.DebugLevel
+	case WarnLevel:
+		return zapcore.WarnLevel
+	case ErrorLevel:
+		return zapcore.ErrorLevel
+	}
+	return zapcore.InfoLevel
+}
+
+func zapFields(fields []Field) []zap.Field {
+	out := make([]zap.Field, len(fields))
+	for i, f := range fields {
+		if err, ok := f.Value.(error); ok {
+			out[i] = zap.NamedError(f.Key, err)
+			continue
+		}
+		out[i] = zap.Any(f.Key, f.Value)
+	}
+	return out
+}
+
+func (l *zapLogger) Enabled(level Level) bool {
+	return l.z.Core().Enabled(zapLevel(level))
+}
+
+func (l *zapLogger) Log(level Level, msg string, fields ...Field) {
+	if ce := l.z.Check(zapLevel(level), msg); ce != nil {
+		ce.Write(zapFields(fields)...)
+	}
+}
+
+func (l *zapLogger) Debug(msg string, fields ...Field) { l.Log(DebugLevel, msg, fields...) }
+func (l *zapLogger) Info(msg string, fields ...Field)  { l.Log(InfoLevel, msg, fields...) }
+func (l *zapLogger) Warn(msg string, fields ...Field)  { l.Log(WarnLevel, msg, fields...) }
+func (l *zapLogger) Error(msg string, fields ...Field) { l.Log(ErrorLevel, msg, fields...) }
+
+func (l *zapLogger) With(fields ...Field) Logger {
+	return &zapLogger{z: l.z.With(zapFields(fields)...)}
+}
+
+func (l *zapLogger) Named(name string) Logger {
+	return &zapLogger{z: l.z.Named(name)}
+}
+
+type logrusLogger struct {
+	l logrus.FieldLogger
+}
+
+func logrusLevel(level Level) logrus.Level {
+	switch level {
+	case DebugLevel:
+		return logrus.DebugLevel
+	case WarnLevel:
+		return logrus.WarnLevel
+	case ErrorLevel:
+		return logrus.ErrorLevel
+	}
+	return logrus.InfoLevel
+}
+
+func logrusFields(fields []Field) logrus.Fields {
+	out := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		out[f.Key] = fieldValue(f.Value)
+	}
+	return out
+}
+
+func (l *logrusLogger) Enabled(level Level) bool {
+	switch v := l.l.(type) {
+	case *logrus.Logger:
+		return v.IsLevelEnabled(logrusLevel(level))
+	case *logrus.Entry:
+		return v.Logger.IsLevelEnabled(logrusLevel(level))
+	}
+	return true
+}
+
+func (l *logrusLogger) Log(level Level, msg string, fields ...Field) {
+	entry := l.l.WithFields(logrusFields(fields))
+	switch level {
+	case DebugLevel:
+		entry.Debug(msg)
+	case WarnLevel:
+		entry.Warn(msg)
+	case ErrorLevel:
+		entry.Error(msg)
+	default:
+		entry.Info(msg)
+	}
+}
+
+func (l *logrusLogger) Debug(msg string, fields ...Field) { l.Log(DebugLevel, msg, fields...) }
+func (l *logrusLogger) Info(msg string, fields ...Field)  { l.Log(InfoLevel, msg, fields...) }
+func (l *logrusLogger) Warn(msg string, fields ...Field)  { l.Log(WarnLevel, msg, fields...) }
+func (l *logrusLogger) Error(msg string, fields ...Field) { l.Log(ErrorLevel, msg, fields...) }
+
+func (l *logrusLogger) With(fields ...Field) Logger {
+	return &logrusLogger{l: l.l.WithFields(logrusFields(fields))}
+}
+
+func (l *logrusLogger) Named(name string) Logger {
+	if entry, ok := l.l.(*logrus.Entry); ok {
+		if parent, ok := entry.Data["logger"].(string); ok {
+			name = joinName(parent, name)
+		}
+	}
+	return &logrusLogger{l: l.l.WithField("logger", name)}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+func slogAttrs(fields []Field) []slog.Attr {
+	out := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		out[i] = slog.Any(f.Key, fieldValue(f.Value))
+	}
+	return out
+}
+
+func (l *slogLogger) Enabled(level Level) bool {
+	return l.l.Enabled(context.Background(), slogLevel(level))
+}
+
+func (l *slogLogger) Log(level Level, msg string, fields ...Field) {
+	l.l.LogAttrs(context.Background(), slogLevel(level), msg, slogAttrs(fields)...)
+}
+
+func (l *slogLogger) Debug(msg string, fields ...Field) { l.Log(DebugLevel, msg, fields...) }
+func (l *slogLogger) Info(msg string, fields ...Field)  { l.Log(InfoLevel, msg, fields...) }
+func (l *slogLogger) Warn(msg string, fields ...Field)  { l.Log(WarnLevel, msg, fields...) }
+func (l *slogLogger) Error(msg string, fields ...Field) { l.Log(ErrorLevel, msg, fields...) }
+
+func (l *slogLogger) With(fields ...Field) Logger {
+	args := make([]any, len(fields))
+	for i, attr := range slogAttrs(fields) {
+		args[i] = attr
+	}
+	return &slogLogger{l: l.l.With(args...)}
+}
+
+func (l *slogLogger) Named(name string) Logger {
+	return &slogLogger{l: l.l.With(slog.String("logger", name))}
+}
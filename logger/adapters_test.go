@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var adapterLevels = []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+
+func TestZapAdapter(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := FromZap(zap.New(core)).Named("action").With(String("request_id", "r1"))
+	for _, level := range adapterLevels {
+		l.Log(level, level.String(), Err(errors.New("boom")))
+	}
+
+	want := []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel}
+	entries := logs.All()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	for i, e := range entries {
+		if e.Level != want[i] || e.LoggerName != "action" {
+			t.Errorf("%s: got level %v logger %q", e.Message, e.Level, e.LoggerName)
+		}
+		fields := e.ContextMap()
+		if fields["request_id"] != "r1" || fields["error"] != "boom" {
+			t.Errorf("%s: fields %v", e.Message, fields)
+		}
+	}
+
+	warnOnly, _ := observer.New(zapcore.WarnLevel)
+	if l := FromZap(zap.New(warnOnly)); l.Enabled(InfoLevel) || !l.Enabled(WarnLevel) {
+		t.Error("Enabled does not follow the zap core level")
+	}
+}
+
+func TestLogrusAdapter(t *testing.T) {
+	base, hook := test.NewNullLogger()
+	base.SetLevel(logrus.DebugLevel)
+	l := FromLogrus(base).Named("action").Named("stream")
+	for _, level := range adapterLevels {
+		l.Log(level, level.String(), Int("n", 1))
+	}
+
+	want := []logrus.Level{logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel}
+	if len(hook.Entries) != len(want) {
+		t.Fatalf("got %d entries", len(hook.Entries))
+	}
+	for i, e := range hook.Entries {
+		if e.Level != want[i] || e.Data["logger"] != "action.stream" || e.Data["n"] != 1 {
+			t.Errorf("%s: got level %v data %v", e.Message, e.Level, e.Data)
+		}
+	}
+
+	base.SetLevel(logrus.WarnLevel)
+	if l.Enabled(InfoLevel) || !l.Enabled(ErrorLevel) {
+		t.Error("Enabled does not follow the logrus level")
+	}
+}
+
+func TestSlogAdapter(t *testing.T) {
+	var out bytes.Buffer
+	l := FromSlog(slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))).Named("cache")
+	for _, level := range adapterLevels {
+		l.Log(level, level.String(), String("key", "k"))
+	}
+
+	want := []string{"DEBUG", "INFO", "WARN", "ERROR"}
+	dec := json.NewDecoder(&out)
+	for i := 0; dec.More(); i++ {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) || record["level"] != want[i] || record["logger"] != "cache" || record["key"] != "k" {
+			t.Errorf("record %d: %v", i, record)
+		}
+	}
+
+	l = FromSlog(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	if l.Enabled(InfoLevel) || !l.Enabled(WarnLevel) {
+		t.Error("Enabled does not follow the slog handler level")
+	}
+}
+
+func TestAdapterPackageLevels(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := FromZap(zap.New(core), map[string]Level{"action": WarnLevel})
+
+	l.Named("action").Info("dropped")
+	l.Named("action").Warn("kept")
+	l.Named("lib").Debug("kept")
+	if n := logs.Len(); n != 2 {
+		t.Errorf("got %d entries, want 2: %v", n, logs.All())
+	}
+
+	l.(interface{ SetLevel(string, Level) }).SetLevel("action", DebugLevel)
+	if !l.Named("action").Enabled(DebugLevel) {
+		t.Error("SetLevel did not apply")
+	}
+}
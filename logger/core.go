@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config configures the built-in backend returned by New.
+type Config struct {
+	// Level applies to packages without an entry in Levels.
+	Level Level
+	// Levels overrides Level per package name as given to For or Named.
+	// "action" also covers "action.stream".
+	Levels map[string]Level
+	// Encoder defaults to JSONEncoder.
+	Encoder Encoder
+	// Output defaults to os.Stderr.
+	Output io.Writer
+	// Sampling limits repeated records; nil logs everything.
+	Sampling *Sampling
+}
+
+// Sampling logs the First records with the same level and message in each
+// Tick, then every Thereafter-th one. Errors are never dropped.
+type Sampling struct {
+	Tick       time.Duration
+	First      int
+	Thereafter int
+}
+
+type core struct {
+	mu      sync.Mutex
+	out     io.Writer
+	encoder Encoder
+	buf     bytes.Buffer
+
+	levels  *levelSet
+	sampler *sampler
+}
+
+// New returns a logger writing records through cfg.Encoder to cfg.Output.
+func New(cfg Config) Logger {
+	c := &core{
+		out:     cfg.Output,
+		encoder: cfg.Encoder,
+		levels:  newLevelSet(cfg.Level, cfg.Levels),
+	}
+	if c.out == nil {
+		c.out = os.Stderr
+	}
+	if c.encoder == nil {
+		c.encoder = JSONEncoder{}
+	}
+	if cfg.Sampling != nil {
+		c.sampler = newSampler(*cfg.Sampling)
+	}
+	return &coreLogger{core: c}
+}
+
+// levelSet holds the default level and per-package overrides.
+type levelSet struct {
+	mu     sync.RWMutex
+	level  Level
+	levels map[string]Level
+}
+
+func newLevelSet(level Level, levels map[string]Level) *levelSet {
+	set := &levelSet{level: level, levels: make(map[string]Level, len(levels))}
+	for name, l := range levels {
+		set.levels[name] = l
+	}
+	return set
+}
+
+// levelFor finds the most specific level configured for name.
+func (s *levelSet) levelFor(name string) Level {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name != "" {
+		if level, ok := s.levels[name]; ok {
+			return level
+		}
+		idx := strings.LastIndex(name, ".")
+		if idx == -1 {
+			break
+		}
+		name = name[:idx]
+	}
+	return s.level
+}
+
+func (s *levelSet) set(pkg string, level Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pkg == "" {
+		s.level = level
+		return
+	}
+	s.levels[pkg] = level
+}
+
+func (c *core) write(entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf.Reset()
+	if err := c.encoder.Encode(&c.buf, entry); err != nil {
+		return
+	}
+	c.out.Write(c.buf.Bytes())
+}
+
+type coreLogger struct {
+	core   *core
+	name   string
+	fields []Field
+}
+
+func (l *coreLogger) Enabled(level Level) bool {
+	return level >= l.core.levels.levelFor(l.name)
+}
+
+func (l *coreLogger) Log(level Level, msg string, fields ...Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	if l.core.sampler != nil && level < ErrorLevel && !l.core.sampler.allow(level, msg) {
+		return
+	}
+	all := fields
+	if len(l.fields) > 0 {
+		all = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	}
+	l.core.write(Entry{Time: time.Now(), Level: level, Logger: l.name, Message: msg, Fields: all})
+}
+
+func (l *coreLogger) Debug(msg string, fields ...Field) { l.Log(DebugLevel, msg, fields...) }
+func (l *coreLogger) Info(msg string, fields ...Field)  { l.Log(InfoLevel, msg, fields...) }
+func (l *coreLogger) Warn(msg string, fields ...Field)  { l.Log(WarnLevel, msg, fields...) }
+func (l *coreLogger) Error(msg string, fields ...Field) { l.Log(ErrorLevel, msg, fields...) }
+
+func (l *coreLogger) With(fields ...Field) Logger {
+	return &coreLogger{core: l.core, name: l.name, fields: append(l.fields[:len(l.fields):len(l.fields)], fields...)}
+}
+
+func (l *coreLogger) Named(name string) Logger {
+	return &coreLogger{core: l.core, name: joinName(l.name, name), fields: l.fields}
+}
+
+// SetLevel changes the level of pkg at runtime; "" sets the default.
+func (l *coreLogger) SetLevel(pkg string, level Level) {
+	l.core.levels.set(pkg, level)
+}
+
+type sampler struct {
+	cfg    Sampling
+	mu     sync.Mutex
+	counts map[sampleKey]*sampleCount
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCount struct {
+	reset time.Time
+	n     int
+}
+
+func newSampler(cfg Sampling) *sampler {
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	if cfg.First <= 0 {
+		cfg.First = 100
+	}
+	return &sampler{cfg: cfg, counts: make(map[sampleKey]*sampleCount)}
+}
+
+func (s *sampler) allow(level Level, msg string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	key := sampleKey{level, msg}
+	count, ok := s.counts[key]
+	if !ok || now.After(count.reset) {
+		if len(s.counts) > 10000 {
+			s.counts = make(map[sampleKey]*sampleCount)
+		}
+		count = &sampleCount{reset: now.Add(s.cfg.Tick)}
+		s.counts[key] = count
+	}
+	count.n++
+	if count.n <= s.cfg.First {
+		return true
+	}
+	return s.cfg.Thereafter > 0 && (count.n-s.cfg.First)%s.cfg.Thereafter == 0
+}
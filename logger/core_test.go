@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelFor(t *testing.T) {
+	set := newLevelSet(InfoLevel, map[string]Level{
+		"action":        DebugLevel,
+		"action.stream": ErrorLevel,
+		"lib":           WarnLevel,
+	})
+	for name, want := range map[string]Level{
+		"":                   InfoLevel,
+		"cache":              InfoLevel,
+		"action":             DebugLevel,
+		"action.bind":        DebugLevel,
+		"action.stream":      ErrorLevel,
+		"action.stream.sse":  ErrorLevel,
+		"actions":            InfoLevel,
+		"lib.config":         WarnLevel,
+		"interceptor.action": InfoLevel,
+	} {
+		if got := set.levelFor(name); got != want {
+			t.Errorf("levelFor(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	set.set("cache", ErrorLevel)
+	set.set("", WarnLevel)
+	if got := set.levelFor("cache.redis"); got != ErrorLevel {
+		t.Errorf("after set: cache.redis = %v", got)
+	}
+	if got := set.levelFor("logger"); got != WarnLevel {
+		t.Errorf("after setting the default: logger = %v", got)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	s := newSampler(Sampling{Tick: time.Hour, First: 3, Thereafter: 5})
+	allowed := 0
+	for i := 0; i < 23; i++ {
+		if s.allow(InfoLevel, "hit") {
+			allowed++
+		}
+	}
+	// The first 3, then the 8th, 13th, 18th and 23rd.
+	if allowed != 7 {
+		t.Errorf("allowed %d of 23 records, want 7", allowed)
+	}
+	if !s.allow(InfoLevel, "other") || !s.allow(WarnLevel, "hit") {
+		t.Error("counts are not kept per level and message")
+	}
+
+	drop := newSampler(Sampling{Tick: time.Hour, First: 1})
+	drop.allow(InfoLevel, "hit")
+	if drop.allow(InfoLevel, "hit") {
+		t.Error("without Thereafter, records past First should be dropped")
+	}
+}
+
+func TestNewLevelsAndSampling(t *testing.T) {
+	var out bytes.Buffer
+	l := New(Config{
+		Level:    WarnLevel,
+		Levels:   map[string]Level{"action": DebugLevel},
+		Encoder:  ConsoleEncoder{},
+		Output:   &out,
+		Sampling: &Sampling{Tick: time.Hour, First: 1},
+	})
+
+	l.Named("lib").Info("hidden")
+	l.Named("action").Named("bind").Debug("shown", String("k", "v"))
+	l.Named("action").Debug("sampled")
+	l.Named("action").Debug("sampled")
+	l.Error("failed")
+	l.Error("failed")
+
+	got := out.String()
+	for _, want := range []string{"\tDEBUG\taction.bind\tshown\tk=v\n", "\tERROR\tfailed\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "hidden") {
+		t.Errorf("info record below the lib level was written:\n%s", got)
+	}
+	if n := strings.Count(got, "sampled"); n != 1 {
+		t.Errorf("sampled record written %d times", n)
+	}
+	if n := strings.Count(got, "failed"); n != 2 {
+		t.Errorf("errors must not be sampled, written %d times", n)
+	}
+
+	l.(interface{ SetLevel(string, Level) }).SetLevel("lib", DebugLevel)
+	if !l.Named("lib").Enabled(DebugLevel) {
+		t.Error("SetLevel did not apply")
+	}
+}
@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single log record as handed to an Encoder.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Logger  string
+	Message string
+	Fields  []Field
+}
+
+// Encoder writes one Entry, including the trailing newline, to buf.
+type Encoder interface {
+	Encode(buf *bytes.Buffer, entry Entry) error
+}
+
+// JSONEncoder writes one JSON object per line:
+//
+//	{"time":"...","level":"warn","logger":"action","msg":"Request failed","code":400}
+type JSONEncoder struct {
+	// TimeLayout defaults to time.RFC3339Nano.
+	TimeLayout string
+}
+
+func (e JSONEncoder) Encode(buf *bytes.Buffer, entry Entry) error {
+	layout := e.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, entry.Time.Format(layout))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, entry.Level.String())
+	if entry.Logger != "" {
+		buf.WriteString(`,"logger":`)
+		writeJSON(buf, entry.Logger)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, entry.Message)
+	for _, f := range entry.Fields {
+		buf.WriteByte(',')
+		writeJSON(buf, f.Key)
+		buf.WriteByte(':')
+		writeJSON(buf, fieldValue(f.Value))
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+func writeJSON(buf *bytes.Buffer, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
+
+// ConsoleEncoder writes human readable lines:
+//
+//	2024-05-01T10:00:00.000Z  WARN  action  Request failed  code=400 path=/users
+type ConsoleEncoder struct {
+	// TimeLayout defaults to "2006-01-02T15:04:05.000Z0700".
+	TimeLayout string
+}
+
+func (e ConsoleEncoder) Encode(buf *bytes.Buffer, entry Entry) error {
+	layout := e.TimeLayout
+	if layout == "" {
+		layout = "2006-01-02T15:04:05.000Z0700"
+	}
+	buf.WriteString(entry.Time.Format(layout))
+	buf.WriteByte('\t')
+	buf.WriteString(strings.ToUpper(entry.Level.String()))
+	if entry.Logger != "" {
+		buf.WriteByte('\t')
+		buf.WriteString(entry.Logger)
+	}
+	buf.WriteByte('\t')
+	buf.WriteString(entry.Message)
+	for i, f := range entry.Fields {
+		if i == 0 {
+			buf.WriteByte('\t')
+		} else {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		buf.WriteString(consoleValue(fieldValue(f.Value)))
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+func consoleValue(value any) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case nil:
+		return "<nil>"
+	case fmt.Stringer:
+		s = v.String()
+	case int, int32, int64, uint, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			return string(data)
+		}
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// fieldValue normalises values that do not encode usefully as is.
+func fieldValue(value any) any {
+	switch v := value.(type) {
+	case error:
+		if v == nil {
+			return nil
+		}
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case []byte:
+		return string(v)
+	}
+	return value
+}
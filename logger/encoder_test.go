@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testEntry = Entry{
+	Time:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	Level:   WarnLevel,
+	Logger:  "action",
+	Message: "Request failed",
+	Fields: []Field{
+		Int("code", 400),
+		String("path", "/users"),
+		String("query", "a b"),
+		Duration("took", 1500*time.Millisecond),
+		Err(errors.New("bad input")),
+		Any("ids", []int{1, 2}),
+	},
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (JSONEncoder{}).Encode(&buf, testEntry); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2024-05-01T10:00:00Z","level":"warn","logger":"action","msg":"Request failed","code":400,"path":"/users","query":"a b","took":"1.5s","error":"bad input","ids":[1,2]}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Error("output is not valid JSON")
+	}
+}
+
+func TestConsoleEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (ConsoleEncoder{}).Encode(&buf, testEntry); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-05-01T10:00:00.000Z\tWARN\taction\tRequest failed\tcode=400 path=/users query=\"a b\" took=1.5s error=\"bad input\" ids=[1,2]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	buf.Reset()
+	(ConsoleEncoder{TimeLayout: time.Kitchen}).Encode(&buf, Entry{Time: testEntry.Time, Level: InfoLevel, Message: "started"})
+	if got := buf.String(); got != "10:00AM\tINFO\tstarted\n" {
+		t.Errorf("without logger and fields: got %q", got)
+	}
+}
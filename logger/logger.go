@@ -0,0 +1,173 @@
+// Package logger is the structured, leveled logging interface used by every
+// package of this module. Packages log through For, whose backend is set
+// once by the application:
+//
+//	logger.SetDefault(logger.New(logger.Config{
+//		Level:   logger.InfoLevel,
+//		Levels:  map[string]logger.Level{"action": logger.DebugLevel},
+//		Encoder: logger.JSONEncoder{},
+//	}))
+//
+// or, to keep an existing backend, logger.SetDefault(logger.FromZap(z)).
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Level int8
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", l)
+}
+
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info", "":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("logger: unknown level %q", s)
+}
+
+// Field is a structured key/value pair attached to a log record.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func String(key string, value string) Field          { return Field{key, value} }
+func Int(key string, value int) Field                { return Field{key, value} }
+func Int32(key string, value int32) Field            { return Field{key, value} }
+func Int64(key string, value int64) Field            { return Field{key, value} }
+func Float64(key string, value float64) Field        { return Field{key, value} }
+func Bool(key string, value bool) Field              { return Field{key, value} }
+func Duration(key string, value time.Duration) Field { return Field{key, value} }
+func Time(key string, value time.Time) Field         { return Field{key, value} }
+func Any(key string, value any) Field                { return Field{key, value} }
+
+// ByteString logs value as text rather than as a byte array.
+func ByteString(key string, value []byte) Field { return Field{key, string(value)} }
+
+// Err attaches err under the "error" key.
+func Err(err error) Field { return Field{"error", err} }
+
+// Logger is implemented by the built-in backend (New) and the zap, logrus
+// and slog adapters.
+type Logger interface {
+	Enabled(level Level) bool
+	Log(level Level, msg string, fields ...Field)
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	// With returns a logger adding fields to every record.
+	With(fields ...Field) Logger
+	// Named returns a logger for a package or component; per-package levels
+	// are keyed by this name.
+	Named(name string) Logger
+}
+
+var defaultLogger atomic.Value
+
+func init() {
+	defaultLogger.Store(holder{New(Config{Level: InfoLevel, Encoder: ConsoleEncoder{}})})
+}
+
+// holder keeps atomic.Value happy with differing concrete types.
+type holder struct{ Logger }
+
+// SetDefault replaces the backend used by every logger returned from For,
+// including the ones created before the call.
+func SetDefault(l Logger) {
+	defaultLogger.Store(holder{l})
+}
+
+func Default() Logger {
+	return defaultLogger.Load().(holder).Logger
+}
+
+// SetLevel changes the level of pkg on the default logger, when it supports
+// per-package levels.
+func SetLevel(pkg string, level Level) bool {
+	if leveled, ok := Default().(interface{ SetLevel(string, Level) }); ok {
+		leveled.SetLevel(pkg, level)
+		return true
+	}
+	return false
+}
+
+// For returns the logger of package pkg. It resolves the default backend on
+// every call, so it can be stored in a package variable.
+func For(pkg string) Logger {
+	return &packageLogger{name: pkg}
+}
+
+type packageLogger struct {
+	name   string
+	fields []Field
+}
+
+func (p *packageLogger) backend() Logger {
+	l := Default().Named(p.name)
+	if len(p.fields) > 0 {
+		l = l.With(p.fields...)
+	}
+	return l
+}
+
+func (p *packageLogger) Enabled(level Level) bool {
+	return Default().Named(p.name).Enabled(level)
+}
+
+func (p *packageLogger) Log(level Level, msg string, fields ...Field) {
+	p.backend().Log(level, msg, fields...)
+}
+
+func (p *packageLogger) Debug(msg string, fields ...Field) { p.Log(DebugLevel, msg, fields...) }
+func (p *packageLogger) Info(msg string, fields ...Field)  { p.Log(InfoLevel, msg, fields...) }
+func (p *packageLogger) Warn(msg string, fields ...Field)  { p.Log(WarnLevel, msg, fields...) }
+func (p *packageLogger) Error(msg string, fields ...Field) { p.Log(ErrorLevel, msg, fields...) }
+
+func (p *packageLogger) With(fields ...Field) Logger {
+	return &packageLogger{name: p.name, fields: append(p.fields[:len(p.fields):len(p.fields)], fields...)}
+}
+
+func (p *packageLogger) Named(name string) Logger {
+	return &packageLogger{name: joinName(p.name, name), fields: p.fields}
+}
+
+func joinName(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	if name == "" {
+		return parent
+	}
+	return parent + "." + name
+}